@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+	"time"
+)
+
+// Stock adjustments API
+type AdjustmentsController struct {
+	BaseController
+}
+
+func (c *AdjustmentsController) URLMapping() {
+	c.Mapping("CreateAdjustment", c.CreateAdjustment)
+}
+
+// @Title CreateAdjustment
+// @Description Adjust a headquarter product stock. Adjustments worth more
+// than the approval threshold wait for an admin.
+// @Accept json
+// @Success 200 {object} models.StockAdjustment
+// @router / [post]
+func (c *AdjustmentsController) CreateAdjustment() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	adjustment := new(models.StockAdjustment)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, adjustment)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(adjustment.UserId) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create adjustment.
+	dao := models.NewStockAdjustmentDao(customerId)
+	err = dao.Create(adjustment)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = adjustment
+	c.ServeJSON()
+}
+
+// @Title GetAdjustment
+// @Description Get stock adjustment.
+// @Param	adjustment_id	path	uint64	true	"Stock adjustment id."
+// @Success 200 {object} models.StockAdjustment
+// @router /:adjustment_id [get]
+func (c *AdjustmentsController) GetAdjustment(adjustment_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate adjustment Id.
+	if adjustment_id == nil {
+		err := fmt.Errorf("adjustment_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Prepare query.
+	adjustment := new(models.StockAdjustment)
+	adjustment.Id = *adjustment_id
+
+	// Get the adjustment.
+	err := models.Read(customerId, adjustment)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = adjustment
+	c.ServeJSON()
+}
+
+// @Title GetAdjustments
+// @Description Get stock adjustments.
+// @Param headquarter_id query uint64 false "Headquarter id."
+// @Param status query string false "Adjustment status."
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *AdjustmentsController) GetAdjustments(headquarter_id uint64, status string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get adjustments.
+	dao := models.NewStockAdjustmentDao(customerId)
+	adjustments, err := dao.FindByHeadquarterAndStatus(headquarter_id, status)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(adjustments)
+	response["adjustments"] = adjustments
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetLosses
+// @Description Get the applied adjustment losses by reason.
+// @Param headquarter_id query uint64 false "Headquarter id."
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {object} map[string]interface{}
+// @router /losses [get]
+func (c *AdjustmentsController) GetLosses(headquarter_id uint64, from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get losses.
+	dao := models.NewStockAdjustmentDao(customerId)
+	losses, err := dao.LossesByReason(headquarter_id, from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Calculate the total value.
+	var value float64
+	for _, loss := range losses {
+		value += loss.Value
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(losses)
+	response["value"] = value
+	response["losses"] = losses
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title ApproveAdjustment
+// @Description Approve a pending stock adjustment applying it to the stock.
+// @Param	adjustment_id	path	uint64	true	"Stock adjustment id."
+// @Param user_id query string true "Admin approving the adjustment."
+// @Success 200 {object} models.StockAdjustment
+// @router /:adjustment_id/approve [patch]
+func (c *AdjustmentsController) ApproveAdjustment(adjustment_id *uint64, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate adjustment Id.
+	if adjustment_id == nil {
+		err := fmt.Errorf("adjustment_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate the admin.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	c.validateAdmin(customerId, user_id)
+
+	// Approve adjustment.
+	dao := models.NewStockAdjustmentDao(customerId)
+	adjustment, err := dao.Approve(*adjustment_id, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = adjustment
+	c.ServeJSON()
+}
+
+// @Title RejectAdjustment
+// @Description Reject a pending stock adjustment.
+// @Param	adjustment_id	path	uint64	true	"Stock adjustment id."
+// @Param user_id query string true "Admin rejecting the adjustment."
+// @Success 200 {object} models.StockAdjustment
+// @router /:adjustment_id/reject [patch]
+func (c *AdjustmentsController) RejectAdjustment(adjustment_id *uint64, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate adjustment Id.
+	if adjustment_id == nil {
+		err := fmt.Errorf("adjustment_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate the admin.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	c.validateAdmin(customerId, user_id)
+
+	// Reject adjustment.
+	dao := models.NewStockAdjustmentDao(customerId)
+	adjustment, err := dao.Reject(*adjustment_id, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = adjustment
+	c.ServeJSON()
+}
@@ -2,6 +2,9 @@ package controllers
 
 import (
 	"app-rest-inventory/models"
+	"app-rest-inventory/util/receipt"
+	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/astaxie/beego/logs"
@@ -10,19 +13,48 @@ import (
 )
 
 type Bill struct {
-	Id            uint64    `json:"id"`
-	HeadquarterId uint64    `json:"headquarter_id"`
-	UserId        string    `json:"user_id"`
-	Discount      float64   `json:"discount"`
-	Sales         []*Sale   `json:"sales"`
-	Created       time.Time `json:"created"`
-	Updated       time.Time `json:"updated"`
+	Id                   uint64            `json:"id"`
+	HeadquarterId        uint64            `json:"headquarter_id"`
+	Number               string            `json:"number"`
+	UserId               string            `json:"user_id"`
+	BuyerId              uint64            `json:"buyer_id"`
+	Discount             float64           `json:"discount"`
+	DiscountType         string            `json:"discount_type"`
+	DiscountRate         float64           `json:"discount_rate"`
+	DiscountAuthorizedBy string            `json:"discount_authorized_by"`
+	CouponCode           string            `json:"coupon_code"`
+	CouponBuyer          string            `json:"coupon_buyer,omitempty"`
+	CouponDiscount       float64           `json:"coupon_discount"`
+	Subtotal             float64           `json:"subtotal"`
+	Tax                  float64           `json:"tax"`
+	Total                float64           `json:"total"`
+	Status               string            `json:"status"`
+	VoidReason           string            `json:"void_reason,omitempty"`
+	VoidedBy             string            `json:"voided_by,omitempty"`
+	Voided               time.Time         `json:"voided"`
+	HoldExpires          time.Time         `json:"hold_expires"`
+	Sales                []*Sale           `json:"sales"`
+	Payments             []*models.Payment `json:"payments"`
+	Created              time.Time         `json:"created"`
+	Updated              time.Time         `json:"updated"`
 }
 
 type Sale struct {
-	Id      uint64   `json:"id"`
-	Amount  uint64   `json:"amount"`
-	Product *Product `json:"product"`
+	Id                uint64   `json:"id"`
+	Amount            uint64   `json:"amount"`
+	UnitPrice         float64  `json:"unit_price"`
+	UnitCost          float64  `json:"unit_cost"`
+	Discount          float64  `json:"discount"`
+	DiscountType      string   `json:"discount_type"`
+	DiscountRate      float64  `json:"discount_rate"`
+	PromotionId       uint64   `json:"promotion_id"`
+	PromotionDiscount float64  `json:"promotion_discount"`
+	TaxRate           float64  `json:"tax_rate"`
+	TaxIncluded       bool     `json:"tax_included"`
+	Subtotal          float64  `json:"subtotal"`
+	Tax               float64  `json:"tax"`
+	Total             float64  `json:"total"`
+	Product           *Product `json:"product"`
 }
 
 type Product struct {
@@ -31,6 +63,12 @@ type Product struct {
 	Price float64 `json:"price"`
 }
 
+// Receipt formats.
+const (
+	ReceiptEscPos = "escpos"
+	ReceiptPDF    = "pdf"
+)
+
 // Bills API
 type BillsController struct {
 	BaseController
@@ -38,6 +76,8 @@ type BillsController struct {
 
 func (c *BillsController) URLMapping() {
 	c.Mapping("CreateBill", c.CreateBill)
+	c.Mapping("PreviewBill", c.PreviewBill)
+	c.Mapping("HoldBill", c.HoldBill)
 }
 
 // @Title CreateBill
@@ -54,93 +94,29 @@ func (c *BillsController) CreateBill() {
 		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Unmarshall request.
-	request := new(Bill)
-	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusBadRequest, err.Error())
-	}
+	// Build bill and sales.
+	request, b, sales := c.parseBill(customerId)
 
-	// Insert bill.
-	b := new(models.Bill)
-	b.HeadquarterId = request.HeadquarterId
-	b.UserId = request.UserId
-	b.Discount = request.Discount
-	err = models.Insert(customerId, b)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	// Insert bill and sales.
+	dao := models.NewBillDao(customerId)
+	err := dao.Create(b, sales, request.Payments)
+	c.serveModelError(err)
 
 	// Update request fields.
-	request.Id = b.Id
-	request.Created = b.Created
-	request.Updated = b.Updated
-
-	// Insert sales.
-	var errors []error
-	for _, sale := range request.Sales {
-		s := new(models.Sale)
-		s.BillId = b.Id
-		s.ProductId = sale.Product.Id
-		s.Amount = sale.Amount
-
-		// Get the product data.
-		dao := models.NewHeadquarterProductDao(customerId)
-		// Get the product.
-		product, err := dao.Read(request.HeadquarterId, sale.Product.Id)
-		if err != nil {
-			logs.Error(err.Error())
-			errors = append(errors, err)
-			continue
-		}
-
-		// Validate stock.
-		if product.HeadquarterProduct.Amount < sale.Amount {
-			err := fmt.Errorf("Product %d does not have enough stock.", sale.Product.Id)
-			errors = append(errors, err)
-			continue
-		}
-
-		// Decrease the current product existences.
-		product.HeadquarterProduct.Amount -= sale.Amount
-		// Update product.
-		err = dao.Update(request.HeadquarterId, sale.Product.Id, &product.HeadquarterProduct)
-		if err != nil {
-			logs.Error(err.Error())
-			errors = append(errors, err)
-			continue
-		}
-
-		// Insert sale.
-		err = models.Insert(customerId, s)
-		if err != nil {
-			logs.Error(err.Error())
-			errors = append(errors, err)
-		}
-
-		// Update sale id.
-		sale.Id = s.Id
-	}
-
-	if len(errors) > 0 {
-		err := fmt.Errorf("Errors creating sales.")
-		// TODO: Delete the bill and sales.
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	updateRequest(request, b, sales)
 
 	// Serve JSON.
 	c.Data["json"] = request
 	c.ServeJSON()
 }
 
-// @Title GetBill
-// @Description Get bill.
-// @Param	bill_id	path	uint64	true	"Bill id."
+// @Title PreviewBill
+// @Description Price a cart with the current prices, promotions and discounts
+// without creating the bill.
+// @Accept json
 // @Success 200  {object} controllers.Bill
-// @router /:bill_id [get]
-func (c *BillsController) GetBill(bill_id *uint64) {
+// @router /preview [post]
+func (c *BillsController) PreviewBill() {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -149,66 +125,60 @@ func (c *BillsController) GetBill(bill_id *uint64) {
 		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Validate sale Id.
-	if bill_id == nil {
-		err := fmt.Errorf("bill_id can not be empty.")
-		logs.Error(err.Error())
-		c.serveError(http.StatusBadRequest, err.Error())
-	}
+	// Build bill and sales.
+	request, b, sales := c.parseBill(customerId)
 
-	// Prepare query.
-	bill := new(models.Bill)
-	bill.Id = *bill_id
+	// Price bill and sales.
+	dao := models.NewBillDao(customerId)
+	err := dao.Preview(b, sales)
+	c.serveModelError(err)
 
-	// Get the bill.
-	err := models.Read(customerId, bill)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	// Update request fields.
+	updateRequest(request, b, sales)
 
-	// Get the sales.
-	dao := models.NewSaleDao(customerId)
-	sales, err := dao.FindByBill(*bill_id)
-	if err != nil {
+	// Serve JSON.
+	c.Data["json"] = request
+	c.ServeJSON()
+}
+
+// @Title HoldBill
+// @Description Park a cart as a held bill. Its sales reserve the stock until
+// the hold expires.
+// @Accept json
+// @Success 200  {object} controllers.Bill
+// @router /held [post]
+func (c *BillsController) HoldBill() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
 		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Build response.
-	response := new(Bill)
-	response.Id = bill.Id
-	response.HeadquarterId = bill.HeadquarterId
-	response.UserId = bill.UserId
-	response.Discount = bill.Discount
-	response.Created = bill.Created
-	response.Updated = bill.Updated
-	response.Sales = make([]*Sale, 0)
+	// Build bill and sales.
+	request, b, sales := c.parseBill(customerId)
 
-	for _, sale := range sales {
-		s := new(Sale)
-		s.Id = sale.Sale.Id
-		s.Amount = sale.Sale.Amount
-		s.Product = new(Product)
-		s.Product.Id = sale.Sale.ProductId
-		s.Product.Name = sale.Product.Name
-		s.Product.Price = sale.Product.Price
+	// Hold bill and sales.
+	dao := models.NewBillDao(customerId)
+	err := dao.Hold(b, sales)
+	c.serveModelError(err)
 
-		response.Sales = append(response.Sales, s)
-	}
+	// Update request fields.
+	updateRequest(request, b, sales)
+	request.HoldExpires = b.HoldExpires
 
 	// Serve JSON.
-	c.Data["json"] = response
+	c.Data["json"] = request
 	c.ServeJSON()
 }
 
-// @Title GetBills
-// @Description Get bills.
-// @Param from query time.Time false "From date"
-// @Param to query time.Time false "To date"
+// @Title GetHeldBills
+// @Description Get the held bills of the headquarter.
+// @Param headquarter_id query uint64 true "Headquarter id."
 // @Success 200 {object} map[string]interface{}
-// @router / [get]
-func (c *BillsController) GetBills(from, to time.Time) {
+// @router /held [get]
+func (c *BillsController) GetHeldBills(headquarter_id uint64) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -217,74 +187,48 @@ func (c *BillsController) GetBills(from, to time.Time) {
 		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Build DAO.
-	dao := models.NewSaleDao(customerId)
+	// Validate headquarter Id.
+	if headquarter_id == 0 {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
 
-	// Get sales.
-	sales, err := dao.FindByDates(from, to)
+	// Get the held bills.
+	bills, err := models.NewBillDao(customerId).FindHeld(headquarter_id)
 	if err != nil {
 		logs.Error(err.Error())
 		c.serveError(http.StatusInternalServerError, err.Error())
 	}
 
-	// Group by bill.
-	bills := make(map[uint64][]*models.SaleBillProduct)
-	for _, sale := range sales {
-		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], sale)
-	}
-
 	// Build response bills.
+	dao := models.NewSaleDao(customerId)
 	bs := make([]*Bill, 0)
-	for bill_id, sales := range bills {
-		// Build bill.
-		b := new(Bill)
-		b.Id = bill_id
-		b.Sales = make([]*Sale, 0)
-
-		for _, sale := range sales {
-			// update bill data.
-			b.HeadquarterId = sale.Bill.HeadquarterId
-			b.UserId = sale.Bill.UserId
-			b.Discount = sale.Bill.Discount
-			b.Created = sale.Bill.Created
-			b.Updated = sale.Bill.Updated
-
-			// Create sale.
-			s := new(Sale)
-			s.Id = sale.Sale.Id
-			s.Amount = sale.Sale.Amount
-			s.Product = new(Product)
-			s.Product.Id = sale.Sale.ProductId
-			s.Product.Name = sale.Product.Name
-			s.Product.Price = sale.Product.Price
-
-			b.Sales = append(b.Sales, s)
+	for _, bill := range bills {
+		sales, err := dao.FindByBill(bill.Id)
+		if err != nil {
+			logs.Error(err.Error())
+			c.serveError(http.StatusInternalServerError, err.Error())
 		}
-
-		// Add to response bills.
-		bs = append(bs, b)
+		bs = append(bs, buildBill(bill, sales))
 	}
 
-	// Get revenue.
-	revenue, _ := dao.RevenueByDates(from, to)
-
 	// Serve JSON.
 	response := make(map[string]interface{})
 	response["total"] = len(bs)
-	response["revenue"] = revenue
 	response["bills"] = bs
 
 	c.Data["json"] = response
 	c.ServeJSON()
 }
 
-// @Title UpdateDiscount
-// @Description Update bill discount.
-// @Accept json
+// @Title ResumeBill
+// @Description Resume a held bill renewing its hold. Expired bills reserve
+// their stock again.
 // @Param bill_id path uint64 true "Bill id."
-// @Success 200 {object} map[string]interface{}
-// @router /:bill_id [patch]
-func (c *BillsController) UpdateDiscount(bill_id *uint64) {
+// @Success 200  {object} controllers.Bill
+// @router /:bill_id/resume [post]
+func (c *BillsController) ResumeBill(bill_id *uint64) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -293,63 +237,90 @@ func (c *BillsController) UpdateDiscount(bill_id *uint64) {
 		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Validate sale Id.
+	// Validate bill Id.
 	if bill_id == nil {
 		err := fmt.Errorf("bill_id can not be empty.")
 		logs.Error(err.Error())
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
 
-	// Unmarshall request.
-	request := new(Bill)
-	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
-	if err != nil {
+	// Resume the bill.
+	dao := models.NewBillDao(customerId)
+	bill, err := dao.Resume(*bill_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.serveBill(customerId, bill)
+}
+
+// @Title FinalizeBill
+// @Description Issue a held bill with the CreateBill validations. The fields
+// left empty keep the held values and the held sales are used when the
+// request has none.
+// @Accept json
+// @Param bill_id path uint64 true "Bill id."
+// @Success 200  {object} controllers.Bill
+// @router /:bill_id/finalize [post]
+func (c *BillsController) FinalizeBill(bill_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
 		logs.Error(err.Error())
-		c.serveError(http.StatusBadRequest, err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Update the bill.
-	b := new(models.Bill)
-	b.Id = *bill_id
-	b.Discount = request.Discount
-	err = models.Update(customerId, *bill_id, b)
-	if err != nil {
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
 		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
 	}
 
-	// Serve JSON.
-	response := make(map[string]interface{})
-	response["discount"] = b.Discount
+	// Build bill and sales.
+	request, b, sales := c.parseBill(customerId)
 
-	c.Data["json"] = response
-	c.ServeJSON()
-}
+	// Issue the bill.
+	dao := models.NewBillDao(customerId)
+	err := dao.Finalize(*bill_id, b, sales, request.Payments)
+	c.serveModelError(err)
 
-// @Title AddSale
-// @Description Add sale to bill.
-// @Accept json
-// @Param bill_id path uint64 true "Bill id."
-// @Param sale_id path uint64 true "Sale id."
-// @router /:bill_id/sales/:sale_id [patch]
-func (c *BillsController) AddSale(bill_id, sale_id *uint64) {
-	c.serveError(http.StatusNotImplemented, "Not implemented yet.")
+	// Serve JSON.
+	c.serveBill(customerId, b)
 }
 
-// @Title RemoveSale
-// @Description Remove sale from bill.
+// @Title CancelHold
+// @Description Cancel a held bill releasing its stock.
 // @Param bill_id path uint64 true "Bill id."
-// @Param sale_id path uint64 true "Sale id."
-// @router /:bill_id/sales/:sale_id [delete]
-func (c *BillsController) RemoveSale(bill_id, sale_id *uint64) {
-	c.serveError(http.StatusNotImplemented, "Not implemented yet.")
+// @router /:bill_id/hold [delete]
+func (c *BillsController) CancelHold(bill_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Cancel the hold.
+	dao := models.NewBillDao(customerId)
+	err := dao.CancelHold(*bill_id)
+	c.serveModelError(err)
 }
 
-// @Title DeleteBill
-// @Description Delete bill.
+// @Title GetBill
+// @Description Get bill.
 // @Param	bill_id	path	uint64	true	"Bill id."
-// @router /:bill_id [delete]
-func (c *BillsController) DeleteBill(bill_id *uint64) {
+// @Success 200  {object} controllers.Bill
+// @router /:bill_id [get]
+func (c *BillsController) GetBill(bill_id *uint64) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -358,7 +329,7 @@ func (c *BillsController) DeleteBill(bill_id *uint64) {
 		c.serveError(http.StatusUnauthorized, err.Error())
 	}
 
-	// Validate bill Id.
+	// Validate sale Id.
 	if bill_id == nil {
 		err := fmt.Errorf("bill_id can not be empty.")
 		logs.Error(err.Error())
@@ -366,24 +337,923 @@ func (c *BillsController) DeleteBill(bill_id *uint64) {
 	}
 
 	// Prepare query.
-	b := new(models.Bill)
-	b.Id = *bill_id
+	bill := new(models.Bill)
+	bill.Id = *bill_id
 
-	// Update the bill.
-	err := models.Delete(customerId, *bill_id, b)
+	// Get the bill.
+	err := models.Read(customerId, bill)
 	if err != nil {
 		logs.Error(err.Error())
 		c.serveError(http.StatusInternalServerError, err.Error())
 	}
 
-	// Build DAO.
+	// Get the sales.
 	dao := models.NewSaleDao(customerId)
+	sales, err := dao.FindByBill(*bill_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
 
-	// Delete sales.
-	err = dao.DeleteByBillId(*bill_id)
+	// Get the payments.
+	payments, err := models.NewPaymentDao(customerId).FindByBill(*bill_id)
 	if err != nil {
 		logs.Error(err.Error())
 		c.serveError(http.StatusInternalServerError, err.Error())
 	}
 
+	// Build response.
+	response := buildBill(bill, sales)
+	response.Payments = payments
+
+	// Serve JSON.
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetReceipt
+// @Description Get the printable bill receipt.
+// @Param bill_id path uint64 true "Bill id."
+// @Param format query string false "Receipt format, escpos or pdf. Default escpos."
+// @Param width query int false "ESC/POS paper width in mm, 58 or 80. Default 80."
+// @Success 200 {string} Receipt document.
+// @router /:bill_id/receipt [get]
+func (c *BillsController) GetReceipt(bill_id *uint64, format string, width int) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate format.
+	if len(format) == 0 {
+		format = ReceiptEscPos
+	}
+	if format != ReceiptEscPos && format != ReceiptPDF {
+		err := fmt.Errorf("format must be %s or %s.", ReceiptEscPos, ReceiptPDF)
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate width.
+	columns := receipt.Width80mm
+	switch width {
+	case 0, 80:
+	case 58:
+		columns = receipt.Width58mm
+	default:
+		err := fmt.Errorf("width must be 58 or 80.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the bill.
+	bill := new(models.Bill)
+	bill.Id = *bill_id
+	err := models.Read(customerId, bill)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Held bills do not have a number yet.
+	if bill.Status == models.BillStatusHeld || bill.Status == models.BillStatusExpired {
+		err := fmt.Errorf("Bill %d is %s.", bill.Id, bill.Status)
+		logs.Error(err.Error())
+		c.serveError(http.StatusConflict, err.Error())
+	}
+
+	// Get the headquarter.
+	headquarter := new(models.Headquarter)
+	headquarter.Id = bill.HeadquarterId
+	err = models.Read(customerId, headquarter)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Get the sales.
+	sales, err := models.NewSaleDao(customerId).FindByBill(*bill_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Get the payments.
+	payments, err := models.NewPaymentDao(customerId).FindByBill(*bill_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve the document.
+	r := buildReceipt(bill, headquarter, sales, payments)
+	if format == ReceiptPDF {
+		c.Ctx.Output.Header("Content-Type", "application/pdf")
+		c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s.pdf\"", bill.Code()))
+		c.Ctx.Output.Body(r.PDF())
+		return
+	}
+	c.Ctx.Output.Header("Content-Type", "application/octet-stream")
+	c.Ctx.Output.Body(r.EscPos(columns))
+}
+
+// @Title GetInvoice
+// @Description Get the bill UBL 2.1 invoice.
+// @Param bill_id path uint64 true "Bill id."
+// @Success 200 {string} Invoice XML document.
+// @router /:bill_id/invoice [get]
+func (c *BillsController) GetInvoice(bill_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the bill.
+	bill := new(models.Bill)
+	bill.Id = *bill_id
+	err := models.Read(customerId, bill)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Held bills do not have a number yet.
+	if bill.Status == models.BillStatusHeld || bill.Status == models.BillStatusExpired {
+		err := fmt.Errorf("Bill %d is %s.", bill.Id, bill.Status)
+		logs.Error(err.Error())
+		c.serveError(http.StatusConflict, err.Error())
+	}
+
+	// Get the sales.
+	sales, err := models.NewSaleDao(customerId).FindByBill(*bill_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Render the invoice.
+	exporter := c.newInvoiceExporter(customerId)
+	document, err := exporter.export(bill, sales)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve XML.
+	c.Ctx.Output.Header("Content-Type", "application/xml")
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.xml\"", bill.Code()))
+	c.Ctx.Output.Body(document)
+}
+
+// @Title GetInvoices
+// @Description Get a zip of the UBL 2.1 invoices of the issued bills.
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {string} Zip file.
+// @router /invoices [get]
+func (c *BillsController) GetInvoices(from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get sales.
+	sales, err := models.NewSaleDao(customerId).FindByDates(from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Group by bill.
+	ids := make([]uint64, 0)
+	bills := make(map[uint64][]*models.SaleBillProduct)
+	for _, sale := range sales {
+		if sale.Bill.Status != models.BillStatusIssued {
+			continue
+		}
+		if _, ok := bills[sale.Sale.BillId]; !ok {
+			ids = append(ids, sale.Sale.BillId)
+		}
+		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], sale)
+	}
+
+	// Render the invoices in a zip.
+	exporter := c.newInvoiceExporter(customerId)
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, id := range ids {
+		bSales := bills[id]
+		bill := bSales[0].Bill
+		document, err := exporter.export(&bill, bSales)
+		if err != nil {
+			logs.Error(err.Error())
+			c.serveError(http.StatusInternalServerError, err.Error())
+		}
+		file, err := archive.Create(fmt.Sprintf("%d-%s.xml", bill.HeadquarterId, bill.Code()))
+		if err != nil {
+			logs.Error(err.Error())
+			c.serveError(http.StatusInternalServerError, err.Error())
+		}
+		_, err = file.Write(document)
+		if err != nil {
+			logs.Error(err.Error())
+			c.serveError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve zip.
+	c.Ctx.Output.Header("Content-Type", "application/zip")
+	c.Ctx.Output.Header("Content-Disposition", "attachment; filename=\"invoices.zip\"")
+	c.Ctx.Output.Body(buffer.Bytes())
+}
+
+// @Title GetBills
+// @Description Get bills.
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *BillsController) GetBills(from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Build DAO.
+	dao := models.NewSaleDao(customerId)
+
+	// Get sales.
+	sales, err := dao.FindByDates(from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Build response bills.
+	bs := buildBills(sales)
+
+	// Get revenue.
+	revenue, _ := dao.RevenueByDates(from, to)
+
+	// Get taxes.
+	tax, _ := dao.TaxByDates(from, to)
+
+	// Get margin.
+	margin, _ := dao.MarginByDates(from, to)
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(bs)
+	response["revenue"] = revenue
+	response["tax"] = tax
+	response["margin"] = margin
+	response["bills"] = bs
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetPayments
+// @Description Get revenue by payment method.
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {object} map[string]interface{}
+// @router /payments [get]
+func (c *BillsController) GetPayments(from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Build DAO.
+	dao := models.NewPaymentDao(customerId)
+
+	// Get revenue by payment method.
+	methods, err := dao.RevenueByMethodAndDates(from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(methods)
+	response["methods"] = methods
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title FindBills
+// @Description Find bills by number.
+// @Param number path uint64 true "Bill number."
+// @Param headquarter_id query uint64 false "Headquarter id."
+// @Success 200 {object} map[string]interface{}
+// @router /numbers/:number [get]
+func (c *BillsController) FindBills(number *uint64, headquarter_id uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate number.
+	if number == nil {
+		err := fmt.Errorf("number can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Find the bills.
+	bills, err := models.NewBillDao(customerId).FindByNumber(*number, headquarter_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Build response bills.
+	dao := models.NewSaleDao(customerId)
+	bs := make([]*Bill, 0)
+	for _, bill := range bills {
+		sales, err := dao.FindByBill(bill.Id)
+		if err != nil {
+			logs.Error(err.Error())
+			c.serveError(http.StatusInternalServerError, err.Error())
+		}
+		bs = append(bs, buildBill(bill, sales))
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(bs)
+	response["bills"] = bs
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title UpdateDiscount
+// @Description Update bill discount.
+// @Accept json
+// @Param bill_id path uint64 true "Bill id."
+// @Success 200 {object} map[string]interface{}
+// @router /:bill_id [patch]
+func (c *BillsController) UpdateDiscount(bill_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate sale Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(Bill)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate the discount authorization.
+	c.validateAdmin(customerId, request.DiscountAuthorizedBy)
+
+	// Build discount.
+	discount := new(models.Bill)
+	discount.Discount = request.Discount
+	discount.DiscountType = request.DiscountType
+	discount.DiscountRate = request.DiscountRate
+	discount.DiscountAuthorizedBy = request.DiscountAuthorizedBy
+
+	// Update the bill.
+	dao := models.NewBillDao(customerId)
+	b, err := dao.UpdateDiscount(*bill_id, discount)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["discount"] = b.Discount
+	response["discount_type"] = b.DiscountType
+	response["discount_rate"] = b.DiscountRate
+	response["discount_authorized_by"] = b.DiscountAuthorizedBy
+	response["total"] = b.Total
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title AddSale
+// @Description Add sale to bill or replace the amount of an existing bill sale.
+// @Accept json
+// @Param bill_id path uint64 true "Bill id."
+// @Param sale_id path uint64 true "Sale id."
+// @Param discount_authorized_by query string false "Admin authorizing the discounts."
+// @Success 200  {object} controllers.Bill
+// @router /:bill_id/sales/:sale_id [patch]
+func (c *BillsController) AddSale(bill_id, sale_id *uint64, discount_authorized_by string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate sale Id.
+	if sale_id == nil {
+		err := fmt.Errorf("sale_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(Sale)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Build sale.
+	s := new(models.Sale)
+	s.Id = *sale_id
+	s.Amount = request.Amount
+	s.Discount = request.Discount
+	s.DiscountType = request.DiscountType
+	s.DiscountRate = request.DiscountRate
+	if request.Product != nil {
+		s.ProductId = request.Product.Id
+	}
+
+	// Validate the discount authorization.
+	c.validateAdmin(customerId, discount_authorized_by)
+
+	// Add the sale.
+	dao := models.NewBillDao(customerId)
+	bill, err := dao.AddSale(*bill_id, s, discount_authorized_by)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.serveBill(customerId, bill)
+}
+
+// @Title RemoveSale
+// @Description Remove sale from bill.
+// @Param bill_id path uint64 true "Bill id."
+// @Param sale_id path uint64 true "Sale id."
+// @Success 200  {object} controllers.Bill
+// @router /:bill_id/sales/:sale_id [delete]
+func (c *BillsController) RemoveSale(bill_id, sale_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate sale Id.
+	if sale_id == nil {
+		err := fmt.Errorf("sale_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Remove the sale.
+	dao := models.NewBillDao(customerId)
+	bill, err := dao.RemoveSale(*bill_id, *sale_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.serveBill(customerId, bill)
+}
+
+// @Title DeleteBill
+// @Description Void bill and restore the stock of its sales.
+// @Param	bill_id	path	uint64	true	"Bill id."
+// @Param user_id query string true "User voiding the bill."
+// @Param reason query string true "Void reason."
+// @Success 200  {object} controllers.Bill
+// @router /:bill_id [delete]
+func (c *BillsController) DeleteBill(bill_id *uint64, user_id, reason string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate reason.
+	if len(reason) == 0 {
+		err := fmt.Errorf("reason can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Void the bill.
+	dao := models.NewBillDao(customerId)
+	bill, err := dao.Void(*bill_id, user_id, reason)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.serveBill(customerId, bill)
+}
+
+// @Title CreateReturn
+// @Description Return bill sales creating a credit note.
+// @Accept json
+// @Param bill_id path uint64 true "Bill id."
+// @Success 200 {object} models.CreditNote
+// @router /:bill_id/returns [post]
+func (c *BillsController) CreateReturn(bill_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	creditNote := new(models.CreditNote)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, creditNote)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	creditNote.BillId = *bill_id
+
+	// Insert credit note.
+	dao := models.NewCreditNoteDao(customerId)
+	err = dao.Create(creditNote)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = creditNote
+	c.ServeJSON()
+}
+
+// @Title GetReturns
+// @Description Get bill returns.
+// @Param bill_id path uint64 true "Bill id."
+// @Success 200 {object} map[string]interface{}
+// @router /:bill_id/returns [get]
+func (c *BillsController) GetReturns(bill_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate bill Id.
+	if bill_id == nil {
+		err := fmt.Errorf("bill_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get credit notes.
+	dao := models.NewCreditNoteDao(customerId)
+	creditNotes, err := dao.FindByBill(*bill_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(creditNotes)
+	response["returns"] = creditNotes
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// serveBill Serves the bill with its current sales.
+// @Param customerId Customer Id.
+// @Param bill Bill to serve.
+func (c *BillsController) serveBill(customerId string, bill *models.Bill) {
+	// Get the sales.
+	dao := models.NewSaleDao(customerId)
+	sales, err := dao.FindByBill(bill.Id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Get the payments.
+	payments, err := models.NewPaymentDao(customerId).FindByBill(bill.Id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := buildBill(bill, sales)
+	response.Payments = payments
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// parseBill Unmarshals the bill request and builds the bill and its sales.
+// @Param customerId Customer Id.
+func (c *BillsController) parseBill(customerId string) (*Bill, *models.Bill, []*models.Sale) {
+	// Unmarshall request.
+	request := new(Bill)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Build bill.
+	b := new(models.Bill)
+	b.HeadquarterId = request.HeadquarterId
+	b.UserId = request.UserId
+	b.BuyerId = request.BuyerId
+	b.Discount = request.Discount
+	b.DiscountType = request.DiscountType
+	b.DiscountRate = request.DiscountRate
+	b.DiscountAuthorizedBy = request.DiscountAuthorizedBy
+	b.CouponCode = request.CouponCode
+	b.CouponBuyer = request.CouponBuyer
+
+	// Validate the discount authorization.
+	c.validateAdmin(customerId, b.DiscountAuthorizedBy)
+
+	// Build sales.
+	sales := make([]*models.Sale, 0)
+	for _, sale := range request.Sales {
+		// Validate product.
+		if sale.Product == nil {
+			err := fmt.Errorf("Every sale must have a product.")
+			logs.Error(err.Error())
+			c.serveError(http.StatusBadRequest, err.Error())
+		}
+
+		// Validate amount.
+		if sale.Amount == 0 {
+			err := fmt.Errorf("Product %d sale amount must be greater than zero.", sale.Product.Id)
+			logs.Error(err.Error())
+			c.serveError(http.StatusBadRequest, err.Error())
+		}
+
+		s := new(models.Sale)
+		s.ProductId = sale.Product.Id
+		s.Amount = sale.Amount
+		s.Discount = sale.Discount
+		s.DiscountType = sale.DiscountType
+		s.DiscountRate = sale.DiscountRate
+		sales = append(sales, s)
+	}
+
+	return request, b, sales
+}
+
+// updateRequest Updates the bill request with the priced bill and sales.
+// @Param request Bill request.
+// @Param b Bill.
+// @Param sales Bill sales in the request order.
+func updateRequest(request *Bill, b *models.Bill, sales []*models.Sale) {
+	request.Id = b.Id
+	if b.Number > 0 {
+		request.Number = b.Code()
+	}
+	request.Discount = b.Discount
+	request.DiscountType = b.DiscountType
+	request.CouponCode = b.CouponCode
+	request.CouponDiscount = b.CouponDiscount
+	request.Subtotal = b.Subtotal
+	request.Tax = b.Tax
+	request.Total = b.Total
+	request.Status = b.Status
+	request.Created = b.Created
+	request.Updated = b.Updated
+	for i, sale := range request.Sales {
+		sale.Id = sales[i].Id
+		sale.UnitPrice = sales[i].UnitPrice
+		sale.UnitCost = sales[i].UnitCost
+		sale.Discount = sales[i].Discount
+		sale.DiscountType = sales[i].DiscountType
+		sale.PromotionId = sales[i].PromotionId
+		sale.PromotionDiscount = sales[i].PromotionDiscount
+		sale.TaxRate = sales[i].TaxRate
+		sale.TaxIncluded = sales[i].TaxIncluded
+		sale.Subtotal = sales[i].Subtotal()
+		sale.Tax = sales[i].Tax()
+		sale.Total = sales[i].Total()
+	}
+}
+
+// buildReceipt Builds the printable receipt of the bill.
+// @Param bill Bill.
+// @Param headquarter Bill headquarter.
+// @Param sales Bill sales.
+// @Param payments Bill payments.
+func buildReceipt(bill *models.Bill, headquarter *models.Headquarter, sales []*models.SaleBillProduct, payments []*models.Payment) *receipt.Receipt {
+	r := new(receipt.Receipt)
+	r.Header = []string{headquarter.Name, headquarter.Address, headquarter.Phone}
+	r.Title = fmt.Sprintf("Bill %s", bill.Code())
+	r.Info = []string{
+		fmt.Sprintf("Date: %s", bill.Created.Format("2006-01-02 15:04")),
+		fmt.Sprintf("Seller: %s", bill.UserId),
+	}
+	if bill.Status == models.BillStatusVoided {
+		r.Info = append(r.Info, "VOIDED")
+	}
+
+	// Lines.
+	for _, sale := range sales {
+		r.Lines = append(r.Lines, receipt.Line{
+			Description: sale.Product.Name,
+			Amount:      sale.Sale.Amount,
+			UnitPrice:   sale.Sale.UnitPrice,
+			Discount:    sale.Sale.Discount + sale.Sale.PromotionDiscount,
+			Total:       sale.Sale.Total(),
+		})
+	}
+
+	// Totals.
+	if bill.CouponDiscount > 0 {
+		r.Totals = append(r.Totals, receipt.Amount{Label: fmt.Sprintf("Coupon %s", bill.CouponCode), Value: -bill.CouponDiscount})
+	}
+	if bill.Discount > 0 {
+		r.Totals = append(r.Totals, receipt.Amount{Label: "Discount", Value: -bill.Discount})
+	}
+	r.Totals = append(r.Totals,
+		receipt.Amount{Label: "Subtotal", Value: bill.Subtotal},
+		receipt.Amount{Label: "Tax", Value: bill.Tax},
+		receipt.Amount{Label: "TOTAL", Value: bill.Total})
+
+	// Payments.
+	for _, payment := range payments {
+		r.Payments = append(r.Payments, receipt.Amount{Label: payment.Method, Value: payment.Tendered})
+		if payment.Change > 0 {
+			r.Payments = append(r.Payments, receipt.Amount{Label: "Change", Value: payment.Change})
+		}
+	}
+
+	return r
+}
+
+// buildBill Builds the bill response.
+// @Param bill Bill.
+// @Param sales Bill sales.
+func buildBill(bill *models.Bill, sales []*models.SaleBillProduct) *Bill {
+	response := new(Bill)
+	response.Id = bill.Id
+	response.HeadquarterId = bill.HeadquarterId
+	if bill.Number > 0 {
+		response.Number = bill.Code()
+	}
+	response.UserId = bill.UserId
+	response.BuyerId = bill.BuyerId
+	response.Discount = bill.Discount
+	response.DiscountType = bill.DiscountType
+	response.DiscountRate = bill.DiscountRate
+	response.DiscountAuthorizedBy = bill.DiscountAuthorizedBy
+	response.CouponCode = bill.CouponCode
+	response.CouponDiscount = bill.CouponDiscount
+	response.Subtotal = bill.Subtotal
+	response.Tax = bill.Tax
+	response.Total = bill.Total
+	response.Status = bill.Status
+	response.VoidReason = bill.VoidReason
+	response.VoidedBy = bill.VoidedBy
+	response.Voided = bill.Voided
+	response.HoldExpires = bill.HoldExpires
+	response.Created = bill.Created
+	response.Updated = bill.Updated
+	response.Sales = make([]*Sale, 0)
+
+	for _, sale := range sales {
+		s := new(Sale)
+		s.Id = sale.Sale.Id
+		s.Amount = sale.Sale.Amount
+		s.UnitPrice = sale.Sale.UnitPrice
+		s.UnitCost = sale.Sale.UnitCost
+		s.Discount = sale.Sale.Discount
+		s.DiscountType = sale.Sale.DiscountType
+		s.DiscountRate = sale.Sale.DiscountRate
+		s.PromotionId = sale.Sale.PromotionId
+		s.PromotionDiscount = sale.Sale.PromotionDiscount
+		s.TaxRate = sale.Sale.TaxRate
+		s.TaxIncluded = sale.Sale.TaxIncluded
+		s.Subtotal = sale.Sale.Subtotal()
+		s.Tax = sale.Sale.Tax()
+		s.Total = sale.Sale.Total()
+		s.Product = new(Product)
+		s.Product.Id = sale.Sale.ProductId
+		s.Product.Name = sale.Product.Name
+		s.Product.Price = sale.Product.Price
+
+		response.Sales = append(response.Sales, s)
+	}
+
+	return response
+}
+
+// buildBills Groups the sales by bill and builds the bills response.
+// @Param sales Sales.
+func buildBills(sales []*models.SaleBillProduct) []*Bill {
+	// Group by bill.
+	ids := make([]uint64, 0)
+	bills := make(map[uint64][]*models.SaleBillProduct)
+	for _, sale := range sales {
+		if _, ok := bills[sale.Sale.BillId]; !ok {
+			ids = append(ids, sale.Sale.BillId)
+		}
+		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], sale)
+	}
+
+	// Build response bills.
+	bs := make([]*Bill, 0)
+	for _, id := range ids {
+		bSales := bills[id]
+		bs = append(bs, buildBill(&bSales[0].Bill, bSales))
+	}
+
+	return bs
 }
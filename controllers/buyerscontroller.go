@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+// Buyers API
+type BuyersController struct {
+	BaseController
+}
+
+func (c *BuyersController) URLMapping() {
+	c.Mapping("CreateBuyer", c.CreateBuyer)
+}
+
+// @Title CreateBuyer
+// @Description Create buyer.
+// @Accept json
+// @Success 200 {object} models.Buyer
+// @router / [post]
+func (c *BuyersController) CreateBuyer() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	buyer := new(models.Buyer)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, buyer)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Insert buyer.
+	dao := models.NewBuyerDao(customerId)
+	err = dao.Create(buyer)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = buyer
+	c.ServeJSON()
+}
+
+// @Title GetBuyer
+// @Description Get buyer.
+// @Param	buyer_id	path	uint64	true	"Buyer id."
+// @Success 200 {object} models.Buyer
+// @router /:buyer_id [get]
+func (c *BuyersController) GetBuyer(buyer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate buyer Id.
+	if buyer_id == nil {
+		err := fmt.Errorf("buyer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Prepare query.
+	buyer := new(models.Buyer)
+	buyer.Id = *buyer_id
+
+	// Get the buyer.
+	err := models.Read(customerId, buyer)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = buyer
+	c.ServeJSON()
+}
+
+// @Title GetBuyers
+// @Description Search buyers by name, document id, email or phone.
+// @Param query query string false "Search text."
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *BuyersController) GetBuyers(query string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Search buyers.
+	buyers, err := models.NewBuyerDao(customerId).Search(query)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(buyers)
+	response["buyers"] = buyers
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetBills
+// @Description Get the buyer purchase history.
+// @Param	buyer_id	path	uint64	true	"Buyer id."
+// @Success 200 {object} map[string]interface{}
+// @router /:buyer_id/bills [get]
+func (c *BuyersController) GetBills(buyer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate buyer Id.
+	if buyer_id == nil {
+		err := fmt.Errorf("buyer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get sales.
+	sales, err := models.NewSaleDao(customerId).FindByBuyerID(*buyer_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Build response bills.
+	bs := buildBills(sales)
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(bs)
+	response["bills"] = bs
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title UpdateBuyer
+// @Description Update buyer.
+// @Accept json
+// @Param	buyer_id	path	uint64	true	"Buyer id."
+// @Success 200 {object} models.Buyer
+// @router /:buyer_id [patch]
+func (c *BuyersController) UpdateBuyer(buyer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate buyer Id.
+	if buyer_id == nil {
+		err := fmt.Errorf("buyer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	buyer := new(models.Buyer)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, buyer)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	buyer.Id = *buyer_id
+
+	// Update the buyer.
+	dao := models.NewBuyerDao(customerId)
+	err = dao.Update(buyer)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = buyer
+	c.ServeJSON()
+}
+
+// @Title DeleteBuyer
+// @Description Delete buyer, the buyers with bills or loyalty points can not
+// be deleted.
+// @Param	buyer_id	path	uint64	true	"Buyer id."
+// @router /:buyer_id [delete]
+func (c *BuyersController) DeleteBuyer(buyer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate buyer Id.
+	if buyer_id == nil {
+		err := fmt.Errorf("buyer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Delete the buyer.
+	dao := models.NewBuyerDao(customerId)
+	err := dao.Delete(*buyer_id)
+	c.serveModelError(err)
+}
+
+// @Title GetPoints
+// @Description Get the buyer loyalty points balance.
+// @Param	buyer_id	path	uint64	true	"Buyer id."
+// @Success 200 {object} map[string]interface{}
+// @router /:buyer_id/points [get]
+func (c *BuyersController) GetPoints(buyer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate buyer Id.
+	if buyer_id == nil {
+		err := fmt.Errorf("buyer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the balance.
+	balance, available, err := models.NewLoyaltyDao(customerId).Balance(*buyer_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["balance"] = balance
+	response["available"] = available
+	response["value"] = float64(available) * models.LoyaltyPointValue
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetPointsHistory
+// @Description Get the buyer loyalty points ledger.
+// @Param	buyer_id	path	uint64	true	"Buyer id."
+// @Success 200 {object} map[string]interface{}
+// @router /:buyer_id/points/history [get]
+func (c *BuyersController) GetPointsHistory(buyer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate buyer Id.
+	if buyer_id == nil {
+		err := fmt.Errorf("buyer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the ledger.
+	entries, err := models.NewLoyaltyDao(customerId).FindByBuyer(*buyer_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(entries)
+	response["entries"] = entries
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
@@ -19,7 +19,8 @@ func (c *CateringsController) URLMapping() {
 }
 
 // @Title CreateCatering
-// @Description Create catering.
+// @Description Create catering increasing the headquarter stock and updating
+// the product cost.
 // @Accept json
 // @Success 200 {object} models.Catering
 // @router / [post]
@@ -40,12 +41,10 @@ func (c *CateringsController) CreateCatering() {
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
 
-	// Insert catering.
-	err = models.Insert(customerId, catering)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	// Create catering.
+	dao := models.NewCateringDao(customerId)
+	err = dao.Create(catering)
+	c.serveModelError(err)
 
 	// Serve JSON.
 	c.Data["json"] = catering
@@ -124,12 +123,13 @@ func (c *CateringsController) GetCaterings(from, to time.Time) {
 }
 
 // @Title UpdateCatering
-// @Description Update catering.
+// @Description Update catering applying the stock difference.
 // @Accept json
 // @Param catering_id path uint64 true "Catering id."
+// @Param user_id query string false "User updating the catering."
 // @Success 200 {object} models.Catering
 // @router /:catering_id [patch]
-func (c *CateringsController) UpdateCatering(catering_id *uint64) {
+func (c *CateringsController) UpdateCatering(catering_id *uint64, user_id string) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -152,14 +152,11 @@ func (c *CateringsController) UpdateCatering(catering_id *uint64) {
 		logs.Error(err.Error())
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
-	catering.Id = *catering_id
 
 	// Update the catering.
-	err = models.Update(customerId, *catering_id, catering)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	dao := models.NewCateringDao(customerId)
+	catering, err = dao.Update(*catering_id, catering, user_id)
+	c.serveModelError(err)
 
 	// Serve JSON.
 	c.Data["json"] = catering
@@ -167,10 +164,11 @@ func (c *CateringsController) UpdateCatering(catering_id *uint64) {
 }
 
 // @Title DeleteCatering
-// @Description Delete catering.
+// @Description Delete catering reversing its stock.
 // @Param	catering_id	path	uint64	true	"Catering id."
+// @Param user_id query string false "User deleting the catering."
 // @router /:catering_id [delete]
-func (c *ProductsController) DeleteCatering(catering_id *uint64) {
+func (c *CateringsController) DeleteCatering(catering_id *uint64, user_id string) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -186,14 +184,8 @@ func (c *ProductsController) DeleteCatering(catering_id *uint64) {
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
 
-	// Prepare query.
-	catering := new(models.Catering)
-	catering.Id = *catering_id
-
-	// Update the catering.
-	err := models.Delete(customerId, *catering_id, catering)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	// Delete the catering.
+	dao := models.NewCateringDao(customerId)
+	err := dao.Delete(*catering_id, user_id)
+	c.serveModelError(err)
 }
@@ -1,8 +1,14 @@
 package controllers
 
 import (
+	"app-rest-inventory/auth0"
+	"app-rest-inventory/models"
 	"app-rest-inventory/util/apierror"
+	"app-rest-inventory/util/stringutil"
+	"fmt"
 	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"net/http"
 )
 
 type BaseController struct {
@@ -18,3 +24,76 @@ func (c *BaseController) serveError(status int, message string) {
 	c.ServeJSON()
 	c.StopRun()
 }
+
+// serveErrorDetails Serves an error response with details.
+// @Param status HTTP response status.
+// @Param message Error message.
+// @Param details Error details.
+func (c *BaseController) serveErrorDetails(status int, message string, details interface{}) {
+	c.Data["json"] = apierror.ApiError{StatusCode: status, ErrorMessage: message, Details: details}
+	c.Ctx.Output.SetStatus(status)
+	c.ServeJSON()
+	c.StopRun()
+}
+
+// serveModelError Serves the error response of a models operation, if any.
+// @Param err Models operation error.
+func (c *BaseController) serveModelError(err error) {
+	if err == nil {
+		return
+	}
+	logs.Error(err.Error())
+
+	switch e := err.(type) {
+	case models.StockErrors:
+		c.serveErrorDetails(http.StatusConflict, e.Error(), e)
+	case *models.StockError:
+		c.serveErrorDetails(http.StatusConflict, e.Error(), models.StockErrors{e})
+	case *models.NotFoundError:
+		c.serveError(http.StatusNotFound, e.Error())
+	case *models.ConflictError:
+		c.serveError(http.StatusConflict, e.Error())
+	case *models.ValidationError:
+		c.serveError(http.StatusBadRequest, e.Error())
+	case *models.ForbiddenError:
+		c.serveError(http.StatusForbidden, e.Error())
+	default:
+		c.serveError(http.StatusInternalServerError, e.Error())
+	}
+}
+
+// validateAdmin Serves a forbidden error unless the authorizing user is an
+// admin of the customer.
+// @Param customerId Customer Id.
+// @Param userId Authorizing user Id, empty when there is no authorization.
+func (c *BaseController) validateAdmin(customerId, userId string) {
+	if len(userId) == 0 {
+		return
+	}
+
+	// Get the customer group.
+	customer, err := auth0.Auth.GetGroup(customerId)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Get the user groups.
+	groups, err := auth0.Auth.GetUserGroups(userId)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Look for the customer admins group.
+	adminGroup := customer.Name + stringutil.HyphenMinus + Admin
+	for _, group := range groups {
+		if group.Name == adminGroup {
+			return
+		}
+	}
+
+	err = fmt.Errorf("User %s is not an admin of the customer.", userId)
+	logs.Error(err.Error())
+	c.serveError(http.StatusForbidden, err.Error())
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+// Coupons API
+type CouponsController struct {
+	BaseController
+}
+
+func (c *CouponsController) URLMapping() {
+	c.Mapping("CreateCoupon", c.CreateCoupon)
+	c.Mapping("GetCoupons", c.GetCoupons)
+}
+
+// @Title CreateCoupon
+// @Description Create coupon.
+// @Accept json
+// @Success 200 {object} models.Coupon
+// @router / [post]
+func (c *CouponsController) CreateCoupon() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	coupon := new(models.Coupon)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, coupon)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	coupon.Uses = 0
+
+	// Validate coupon.
+	c.serveModelError(coupon.Validate())
+	if len(coupon.Code) == 0 {
+		err := fmt.Errorf("code can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Insert coupon.
+	err = models.Insert(customerId, coupon)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = coupon
+	c.ServeJSON()
+}
+
+// @Title GetCoupon
+// @Description Get coupon.
+// @Param	coupon_id	path	uint64	true	"Coupon id."
+// @Success 200 {object} models.Coupon
+// @router /:coupon_id [get]
+func (c *CouponsController) GetCoupon(coupon_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate coupon Id.
+	if coupon_id == nil {
+		err := fmt.Errorf("coupon_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Prepare query.
+	coupon := new(models.Coupon)
+	coupon.Id = *coupon_id
+
+	// Get the coupon.
+	err := models.Read(customerId, coupon)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = coupon
+	c.ServeJSON()
+}
+
+// @Title GetCoupons
+// @Description Get coupons.
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *CouponsController) GetCoupons() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	coupons := make([]*models.Coupon, 0)
+	err := models.ReadAll(customerId, &coupons)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(coupons)
+	response["coupons"] = coupons
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title UpdateCoupon
+// @Description Update coupon.
+// @Accept json
+// @Param	coupon_id	path	uint64	true	"Coupon id."
+// @Success 200 {object} models.Coupon
+// @router /:coupon_id [patch]
+func (c *CouponsController) UpdateCoupon(coupon_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate coupon Id.
+	if coupon_id == nil {
+		err := fmt.Errorf("coupon_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	coupon := new(models.Coupon)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, coupon)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	coupon.Id = *coupon_id
+	coupon.Uses = 0
+
+	// Validate coupon.
+	c.serveModelError(coupon.Validate())
+
+	// Update the coupon.
+	err = models.Update(customerId, *coupon_id, coupon)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = coupon
+	c.ServeJSON()
+}
+
+// @Title DeleteCoupon
+// @Description Delete coupon, the redeemed coupons can not be deleted.
+// @Param	coupon_id	path	uint64	true	"Coupon id."
+// @router /:coupon_id [delete]
+func (c *CouponsController) DeleteCoupon(coupon_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate coupon Id.
+	if coupon_id == nil {
+		err := fmt.Errorf("coupon_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Delete the coupon.
+	dao := models.NewCouponDao(customerId)
+	err := dao.Delete(*coupon_id)
+	c.serveModelError(err)
+}
+
+// @Title GetRedemptions
+// @Description Get coupon redemptions.
+// @Param	coupon_id	path	uint64	true	"Coupon id."
+// @Success 200 {object} map[string]interface{}
+// @router /:coupon_id/redemptions [get]
+func (c *CouponsController) GetRedemptions(coupon_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate coupon Id.
+	if coupon_id == nil {
+		err := fmt.Errorf("coupon_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the redemptions.
+	redemptions, err := models.NewCouponDao(customerId).FindRedemptions(*coupon_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(redemptions)
+	response["redemptions"] = redemptions
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
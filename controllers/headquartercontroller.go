@@ -40,6 +40,7 @@ func (c *HeadquartersController) CreateHeadquarter() {
 		logs.Error(err.Error())
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
+	headquarter.LastBillNumber = 0
 
 	// Insert headquarter.
 	err = models.Insert(customerId, headquarter)
@@ -149,6 +150,7 @@ func (c *HeadquartersController) UpdateHeadquarter(headquarter_id *uint64) {
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
 	headquarter.Id = *headquarter_id
+	headquarter.LastBillNumber = 0
 
 	// Update the headquarter.
 	err = models.Update(customerId, *headquarter_id, headquarter)
@@ -185,6 +187,7 @@ func (c *HeadquartersController) DeleteHeadquarter(headquarter_id *uint64) {
 	// Prepare query.
 	headquarter := new(models.Headquarter)
 	headquarter.Id = *headquarter_id
+	headquarter.LastBillNumber = 0
 
 	// Update the headquarter.
 	err := models.Delete(customerId, *headquarter_id, headquarter)
@@ -198,9 +201,10 @@ func (c *HeadquartersController) DeleteHeadquarter(headquarter_id *uint64) {
 // @Description Add product to headquarter.
 // @Accept json
 // @Param	headquarter_id	path	uint64	true	"Headquarter id."
+// @Param user_id query string false "User adding the product."
 // @Success 200 {object} models.HeadquarterProduct
 // @router /:headquarter_id/products [post]
-func (c *HeadquartersController) AddProduct(headquarter_id *uint64) {
+func (c *HeadquartersController) AddProduct(headquarter_id *uint64, user_id string) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -224,14 +228,15 @@ func (c *HeadquartersController) AddProduct(headquarter_id *uint64) {
 		c.serveError(http.StatusBadRequest, err.Error())
 	}
 
-	// Add product.
+	// Add product, the reservations belong to the held bills and the freeze to
+	// the inventory counts.
 	headquarterProduct.HeadquarterId = *headquarter_id
+	headquarterProduct.Reserved = 0
+	headquarterProduct.Frozen = false
 
-	err = models.Insert(customerId, headquarterProduct)
-	if err != nil {
-		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
-	}
+	dao := models.NewHeadquarterProductDao(customerId)
+	err = dao.Create(headquarterProduct, user_id)
+	c.serveModelError(err)
 
 	// Serve JSON.
 	c.Data["json"] = headquarterProduct
@@ -239,13 +244,15 @@ func (c *HeadquartersController) AddProduct(headquarter_id *uint64) {
 }
 
 // @Title UpdateProduct
-// @Description Update headquarter product.
+// @Description Set the headquarter product stock through a count correction
+// stock adjustment.
 // @Accept json
 // @Param	headquarter_id	path	uint64	true	"Headquarter id."
 // @Param	product_id	path	uint64	true	"Product id."
+// @Param user_id query string false "User updating the product."
 // @Success 200 {object} models.HeadquarterProduct
 // @router /:headquarter_id/products/:product_id [patch]
-func (c *HeadquartersController) UpdateProduct(headquarter_id, product_id *uint64) {
+func (c *HeadquartersController) UpdateProduct(headquarter_id, product_id *uint64, user_id string) {
 	// Get customer Id from the cookies.
 	customerId := c.Ctx.GetCookie("customer_id")
 	if len(customerId) == 0 {
@@ -273,17 +280,141 @@ func (c *HeadquartersController) UpdateProduct(headquarter_id, product_id *uint6
 	dao := models.NewHeadquarterProductDao(customerId)
 
 	// Update product.
-	err = dao.Update(*headquarter_id, *product_id, headquarterProduct)
+	err = dao.Update(*headquarter_id, *product_id, headquarterProduct, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = headquarterProduct
+	c.ServeJSON()
+}
+
+// @Title UpdateLevels
+// @Description Set the headquarter product reorder levels, 0 removes a
+// level.
+// @Accept json
+// @Param	headquarter_id	path	uint64	true	"Headquarter id."
+// @Param	product_id	path	uint64	true	"Product id."
+// @Success 200 {object} models.HeadquarterProduct
+// @router /:headquarter_id/products/:product_id/levels [patch]
+func (c *HeadquartersController) UpdateLevels(headquarter_id, product_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == nil {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate product Id.
+	if product_id == nil {
+		err := fmt.Errorf("product_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	levels := new(models.HeadquarterProduct)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, levels)
 	if err != nil {
 		logs.Error(err.Error())
-		c.serveError(http.StatusInternalServerError, err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
 	}
 
+	// Update the levels.
+	dao := models.NewHeadquarterProductDao(customerId)
+	headquarterProduct, err := dao.UpdateLevels(*headquarter_id, *product_id, levels.Minimum, levels.Maximum)
+	c.serveModelError(err)
+
 	// Serve JSON.
 	c.Data["json"] = headquarterProduct
 	c.ServeJSON()
 }
 
+// @Title GetLowStock
+// @Description Get the headquarter products at or below their minimum level.
+// @Param	headquarter_id	path	uint64	true	"Headquarter id."
+// @Success 200 {object} map[string]interface{}
+// @router /:headquarter_id/products/low-stock [get]
+func (c *HeadquartersController) GetLowStock(headquarter_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == nil {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the low stock products.
+	dao := models.NewHeadquarterProductDao(customerId)
+	products, err := dao.FindLowStock(*headquarter_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(products)
+	response["products"] = products
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetAlerts
+// @Description Get the headquarter low stock alerts.
+// @Param	headquarter_id	path	uint64	true	"Headquarter id."
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {object} map[string]interface{}
+// @router /:headquarter_id/alerts [get]
+func (c *HeadquartersController) GetAlerts(headquarter_id *uint64, from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == nil {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the alerts.
+	dao := models.NewStockAlertDao(customerId)
+	alerts, err := dao.FindByHeadquarter(*headquarter_id, from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(alerts)
+	response["alerts"] = alerts
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
 // @Title GetProduct
 // @Description Get headquarter product.
 // @Param	headquarter_id	path	uint64	true	"Headquarter id."
@@ -327,6 +458,55 @@ func (c *HeadquartersController) GetProduct(headquarter_id, product_id *uint64)
 	c.ServeJSON()
 }
 
+// @Title GetMovements
+// @Description Get the stock movements of the headquarter product, the latest
+// first.
+// @Param	headquarter_id	path	uint64	true	"Headquarter id."
+// @Param	product_id	path	uint64	true	"Product id."
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {object} map[string]interface{}
+// @router /:headquarter_id/products/:product_id/movements [get]
+func (c *HeadquartersController) GetMovements(headquarter_id, product_id *uint64, from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == nil {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate product Id.
+	if product_id == nil {
+		err := fmt.Errorf("product_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the movements.
+	dao := models.NewStockMovementDao(customerId)
+	movements, err := dao.FindByHeadquarterAndProduct(*headquarter_id, *product_id, from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(movements)
+	response["movements"] = movements
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
 // @Title GetProduct
 // @Description Get headquarter product.
 // @Param	headquarter_id	path	uint64	true	"Headquarter id."
@@ -459,54 +639,69 @@ func (c *HeadquartersController) GetBills(headquarter_id *uint64, from, to time.
 		c.serveError(http.StatusInternalServerError, err.Error())
 	}
 
-	// Group by bill.
-	bills := make(map[uint64][]*models.SaleBillProduct)
-	for _, sale := range sales {
-		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], sale)
-	}
-
 	// Build response bills.
-	bs := make([]*Bill, 0)
-	for bill_id, sales := range bills {
-		// Build bill.
-		b := new(Bill)
-		b.Id = bill_id
-		b.Sales = make([]*Sale, 0)
-
-		for _, sale := range sales {
-			// update bill data.
-			b.HeadquarterId = sale.Bill.HeadquarterId
-			b.UserId = sale.Bill.UserId
-			b.Discount = sale.Bill.Discount
-			b.Created = sale.Bill.Created
-			b.Updated = sale.Bill.Updated
-
-			// Create sale.
-			s := new(Sale)
-			s.Id = sale.Sale.Id
-			s.Amount = sale.Sale.Amount
-			s.Product = new(Product)
-			s.Product.Id = sale.Sale.ProductId
-			s.Product.Name = sale.Product.Name
-			s.Product.Price = sale.Product.Price
-
-			b.Sales = append(b.Sales, s)
-		}
-
-		// Add to response bills.
-		bs = append(bs, b)
-	}
+	bs := buildBills(sales)
 
 	// Get revenue.
 	revenue, _ := dao.RevenueByHeadquarterIDAndDates(*headquarter_id, from, to)
 
+	// Get taxes.
+	tax, _ := dao.TaxByHeadquarterIDAndDates(*headquarter_id, from, to)
+
+	// Get margin.
+	margin, _ := dao.MarginByHeadquarterIDAndDates(*headquarter_id, from, to)
+
 	// Serve JSON.
 	response := make(map[string]interface{})
 	response["total"] = len(bs)
 	response["revenue"] = revenue
+	response["tax"] = tax
+	response["margin"] = margin
 	response["bills"] = bs
 
 	c.Data["json"] = response
 	c.ServeJSON()
 
 }
+
+// @Title GetPayments
+// @Description Get headquarter revenue by payment method.
+// @Param	headquarter_id	path	uint64	true	"Headquarter id."
+// @Param from query time.Time false "From date"
+// @Param to query time.Time false "To date"
+// @Success 200 {object} map[string]interface{}
+// @router /:headquarter_id/payments [get]
+func (c *HeadquartersController) GetPayments(headquarter_id *uint64, from, to time.Time) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == nil {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Build DAO.
+	dao := models.NewPaymentDao(customerId)
+
+	// Get revenue by payment method.
+	methods, err := dao.RevenueByMethodAndHeadquarterIDAndDates(*headquarter_id, from, to)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(methods)
+	response["methods"] = methods
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
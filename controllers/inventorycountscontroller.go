@@ -0,0 +1,333 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+type CreateInventoryCount struct {
+	HeadquarterId uint64   `json:"headquarter_id"`
+	UserId        string   `json:"user_id"`
+	Notes         string   `json:"notes"`
+	ProductIds    []uint64 `json:"product_ids"`
+}
+
+type CountInventory struct {
+	UserId  string                        `json:"user_id"`
+	Entries []*models.InventoryCountEntry `json:"entries"`
+}
+
+// Inventory counts API
+type InventoryCountsController struct {
+	BaseController
+}
+
+func (c *InventoryCountsController) URLMapping() {
+	c.Mapping("CreateInventoryCount", c.CreateInventoryCount)
+}
+
+// @Title CreateInventoryCount
+// @Description Open an inventory count taking the expected quantities from
+// the headquarter stock.
+// @Accept json
+// @Success 200 {object} models.InventoryCount
+// @router / [post]
+func (c *InventoryCountsController) CreateInventoryCount() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(CreateInventoryCount)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(request.UserId) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Open the inventory count.
+	count := new(models.InventoryCount)
+	count.HeadquarterId = request.HeadquarterId
+	count.UserId = request.UserId
+	count.Notes = request.Notes
+
+	dao := models.NewInventoryCountDao(customerId)
+	err = dao.Create(count, request.ProductIds)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = count
+	c.ServeJSON()
+}
+
+// @Title GetInventoryCount
+// @Description Get inventory count with its lines.
+// @Param	count_id	path	uint64	true	"Inventory count id."
+// @Success 200 {object} models.InventoryCount
+// @router /:count_id [get]
+func (c *InventoryCountsController) GetInventoryCount(count_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate count Id.
+	if count_id == nil {
+		err := fmt.Errorf("count_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the inventory count.
+	dao := models.NewInventoryCountDao(customerId)
+	count, err := dao.Read(*count_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = count
+	c.ServeJSON()
+}
+
+// @Title GetInventoryCounts
+// @Description Get inventory counts.
+// @Param headquarter_id query uint64 false "Headquarter id."
+// @Param status query string false "Inventory count status."
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *InventoryCountsController) GetInventoryCounts(headquarter_id uint64, status string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get inventory counts.
+	dao := models.NewInventoryCountDao(customerId)
+	counts, err := dao.FindByHeadquarterAndStatus(headquarter_id, status)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(counts)
+	response["counts"] = counts
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetVariances
+// @Description Get the counted products whose quantity differs from the
+// expected one, valued at cost.
+// @Param	count_id	path	uint64	true	"Inventory count id."
+// @Success 200 {object} map[string]interface{}
+// @router /:count_id/variances [get]
+func (c *InventoryCountsController) GetVariances(count_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate count Id.
+	if count_id == nil {
+		err := fmt.Errorf("count_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the variances.
+	dao := models.NewInventoryCountDao(customerId)
+	lines, err := dao.Variances(*count_id)
+	c.serveModelError(err)
+
+	// Calculate the total value.
+	var value float64
+	for _, line := range lines {
+		value += line.Value
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(lines)
+	response["value"] = value
+	response["lines"] = lines
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title CountInventory
+// @Description Add counted units to the inventory count. Several counters
+// can send their entries in batches.
+// @Accept json
+// @Param	count_id	path	uint64	true	"Inventory count id."
+// @Success 200 {object} models.InventoryCount
+// @router /:count_id/entries [post]
+func (c *InventoryCountsController) CountInventory(count_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate count Id.
+	if count_id == nil {
+		err := fmt.Errorf("count_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(CountInventory)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(request.UserId) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Count.
+	dao := models.NewInventoryCountDao(customerId)
+	count, err := dao.Count(*count_id, request.Entries, request.UserId)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = count
+	c.ServeJSON()
+}
+
+// @Title ReconcileInventoryCount
+// @Description Close the inventory count to new entries and freeze the sales
+// of the counted products.
+// @Param	count_id	path	uint64	true	"Inventory count id."
+// @Success 200 {object} models.InventoryCount
+// @router /:count_id/reconcile [patch]
+func (c *InventoryCountsController) ReconcileInventoryCount(count_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate count Id.
+	if count_id == nil {
+		err := fmt.Errorf("count_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Reconcile the inventory count.
+	dao := models.NewInventoryCountDao(customerId)
+	count, err := dao.Reconcile(*count_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = count
+	c.ServeJSON()
+}
+
+// @Title ApproveInventoryCount
+// @Description Approve the inventory count posting its variances as stock
+// adjustments.
+// @Param	count_id	path	uint64	true	"Inventory count id."
+// @Param user_id query string true "Admin approving the count."
+// @Success 200 {object} models.InventoryCount
+// @router /:count_id/approve [patch]
+func (c *InventoryCountsController) ApproveInventoryCount(count_id *uint64, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate count Id.
+	if count_id == nil {
+		err := fmt.Errorf("count_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate the admin.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	c.validateAdmin(customerId, user_id)
+
+	// Approve the inventory count.
+	dao := models.NewInventoryCountDao(customerId)
+	count, err := dao.Approve(*count_id, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = count
+	c.ServeJSON()
+}
+
+// @Title CancelInventoryCount
+// @Description Cancel the inventory count without changing the stock.
+// @Param	count_id	path	uint64	true	"Inventory count id."
+// @Success 200 {object} models.InventoryCount
+// @router /:count_id/cancel [patch]
+func (c *InventoryCountsController) CancelInventoryCount(count_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate count Id.
+	if count_id == nil {
+		err := fmt.Errorf("count_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Cancel the inventory count.
+	dao := models.NewInventoryCountDao(customerId)
+	count, err := dao.Cancel(*count_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = count
+	c.ServeJSON()
+}
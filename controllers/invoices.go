@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"app-rest-inventory/auth0"
+	"app-rest-inventory/models"
+	"app-rest-inventory/util/ubl"
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// invoiceExporter Renders bills as UBL 2.1 invoices. The headquarters and
+// buyers are cached so batch exports read them once.
+type invoiceExporter struct {
+	customerId   string
+	customer     *auth0.Group
+	currency     string
+	country      string
+	headquarters map[uint64]*models.Headquarter
+	buyers       map[uint64]*models.Buyer
+}
+
+// newInvoiceExporter Builds the invoice exporter of the customer.
+// @Param customerId Customer Id.
+func (c *BillsController) newInvoiceExporter(customerId string) *invoiceExporter {
+	// Get the tenant data.
+	customer, err := auth0.Auth.GetGroup(customerId)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	e := new(invoiceExporter)
+	e.customerId = customerId
+	e.customer = customer
+	e.currency = beego.AppConfig.DefaultString("invoices::currency", "USD")
+	e.country = beego.AppConfig.String("invoices::country")
+	e.headquarters = make(map[uint64]*models.Headquarter)
+	e.buyers = make(map[uint64]*models.Buyer)
+	return e
+}
+
+// export Renders the bill invoice XML document.
+// @Param bill Bill to export.
+// @Param sales Bill sales.
+func (e *invoiceExporter) export(bill *models.Bill, sales []*models.SaleBillProduct) ([]byte, error) {
+	invoice := ubl.NewInvoice(bill.Code(), e.currency)
+	invoice.IssueDate = bill.Created.Format("2006-01-02")
+	invoice.IssueTime = bill.Created.Format("15:04:05")
+	if bill.Status == models.BillStatusVoided {
+		invoice.Note = fmt.Sprintf("Voided: %s", bill.VoidReason)
+	}
+
+	// Supplier, the tenant and the bill headquarter.
+	headquarter, err := e.headquarter(bill.HeadquarterId)
+	if err != nil {
+		return nil, err
+	}
+	supplier := ubl.Party{PartyName: ubl.PartyName{Name: e.customer.Name}}
+	supplier.PostalAddress = e.address(headquarter.Address)
+	if len(headquarter.Phone) > 0 {
+		supplier.Contact = &ubl.Contact{Telephone: headquarter.Phone}
+	}
+	invoice.AccountingSupplierParty.Party = supplier
+
+	// Customer, the buyer when the bill has one or the final consumer.
+	if bill.BuyerId > 0 {
+		buyer, err := e.buyer(bill.BuyerId)
+		if err != nil {
+			return nil, err
+		}
+		customer := ubl.Party{PartyName: ubl.PartyName{Name: buyer.Name}}
+		if len(buyer.DocumentId) > 0 {
+			customer.PartyIdentification = &ubl.Identification{ID: buyer.DocumentId}
+		}
+		customer.PostalAddress = e.address(buyer.Address)
+		if len(buyer.Phone) > 0 || len(buyer.Email) > 0 {
+			customer.Contact = &ubl.Contact{Telephone: buyer.Phone, ElectronicMail: buyer.Email}
+		}
+		invoice.AccountingCustomerParty.Party = customer
+	}
+
+	// Lines.
+	var lines float64
+	taxable := make(map[float64]float64)
+	taxes := make(map[float64]float64)
+	for i, sale := range sales {
+		line := ubl.InvoiceLine{}
+		line.ID = strconv.Itoa(i + 1)
+		line.InvoicedQuantity = ubl.Quantity{Value: sale.Sale.Amount, UnitCode: ubl.UnitCode}
+		line.LineExtensionAmount = ubl.NewAmount(sale.Sale.Subtotal(), e.currency)
+		if discount := sale.Sale.Discount + sale.Sale.PromotionDiscount; discount > 0 {
+			// Line discounts without taxes.
+			if sale.Sale.TaxIncluded {
+				discount = discount / (1 + sale.Sale.TaxRate/100)
+			}
+			line.AllowanceCharges = append(line.AllowanceCharges, ubl.AllowanceCharge{
+				AllowanceChargeReason: "Discount",
+				Amount:                ubl.NewAmount(discount, e.currency),
+			})
+		}
+		line.Item.Name = sale.Product.Name
+		line.Item.SellersItemIdentification = &ubl.Identification{ID: strconv.FormatUint(sale.Product.Id, 10)}
+		line.Item.ClassifiedTaxCategory = e.taxCategory(sale.Sale.TaxRate)
+		line.Price.PriceAmount = ubl.NewAmount(e.netPrice(&sale.Sale), e.currency)
+		invoice.InvoiceLines = append(invoice.InvoiceLines, line)
+
+		lines += sale.Sale.Subtotal()
+		taxable[sale.Sale.TaxRate] += sale.Sale.Subtotal()
+		taxes[sale.Sale.TaxRate] += sale.Sale.Tax()
+	}
+
+	// Bill discounts, they reduce the subtotal and the taxes proportionally.
+	allowance := lines - bill.Subtotal
+	if allowance > 0.005 {
+		invoice.AllowanceCharges = append(invoice.AllowanceCharges, ubl.AllowanceCharge{
+			AllowanceChargeReason: "Discount",
+			Amount:                ubl.NewAmount(allowance, e.currency),
+		})
+	}
+	factor := 1.0
+	if lines > 0 {
+		factor = bill.Subtotal / lines
+	}
+
+	// Taxes by rate.
+	rates := make([]float64, 0)
+	for rate := range taxable {
+		rates = append(rates, rate)
+	}
+	sort.Float64s(rates)
+	invoice.TaxTotal.TaxAmount = ubl.NewAmount(bill.Tax, e.currency)
+	for _, rate := range rates {
+		invoice.TaxTotal.TaxSubtotals = append(invoice.TaxTotal.TaxSubtotals, ubl.TaxSubtotal{
+			TaxableAmount: ubl.NewAmount(taxable[rate]*factor, e.currency),
+			TaxAmount:     ubl.NewAmount(taxes[rate]*factor, e.currency),
+			TaxCategory:   e.taxCategory(rate),
+		})
+	}
+
+	// Totals.
+	invoice.LegalMonetaryTotal = ubl.LegalMonetaryTotal{
+		LineExtensionAmount:  ubl.NewAmount(lines, e.currency),
+		TaxExclusiveAmount:   ubl.NewAmount(bill.Subtotal, e.currency),
+		TaxInclusiveAmount:   ubl.NewAmount(bill.Total, e.currency),
+		AllowanceTotalAmount: ubl.NewAmount(allowance, e.currency),
+		PayableAmount:        ubl.NewAmount(bill.Total, e.currency),
+	}
+
+	return invoice.Marshal()
+}
+
+// netPrice Unit price without taxes.
+// @Param sale Sale line.
+func (e *invoiceExporter) netPrice(sale *models.Sale) float64 {
+	if sale.TaxIncluded {
+		return sale.UnitPrice / (1 + sale.TaxRate/100)
+	}
+	return sale.UnitPrice
+}
+
+// taxCategory Builds the VAT category of the rate.
+// @Param rate Tax rate in percent.
+func (e *invoiceExporter) taxCategory(rate float64) ubl.TaxCategory {
+	return ubl.TaxCategory{
+		Percent:   strconv.FormatFloat(rate, 'f', -1, 64),
+		TaxScheme: ubl.TaxScheme{ID: ubl.TaxSchemeVAT},
+	}
+}
+
+// address Builds the postal address, nil when there is no address.
+// @Param street Street address.
+func (e *invoiceExporter) address(street string) *ubl.PostalAddress {
+	if len(street) == 0 && len(e.country) == 0 {
+		return nil
+	}
+	address := &ubl.PostalAddress{StreetName: street}
+	if len(e.country) > 0 {
+		address.Country = &ubl.Country{IdentificationCode: e.country}
+	}
+	return address
+}
+
+// headquarter Gets the headquarter from the cache or the database.
+// @Param headquarterId Headquarter Id.
+func (e *invoiceExporter) headquarter(headquarterId uint64) (*models.Headquarter, error) {
+	if headquarter, ok := e.headquarters[headquarterId]; ok {
+		return headquarter, nil
+	}
+	headquarter := new(models.Headquarter)
+	headquarter.Id = headquarterId
+	err := models.Read(e.customerId, headquarter)
+	if err != nil {
+		return nil, err
+	}
+	e.headquarters[headquarterId] = headquarter
+	return headquarter, nil
+}
+
+// buyer Gets the buyer from the cache or the database.
+// @Param buyerId Buyer Id.
+func (e *invoiceExporter) buyer(buyerId uint64) (*models.Buyer, error) {
+	if buyer, ok := e.buyers[buyerId]; ok {
+		return buyer, nil
+	}
+	buyer := new(models.Buyer)
+	buyer.Id = buyerId
+	err := models.Read(e.customerId, buyer)
+	if err != nil {
+		return nil, err
+	}
+	e.buyers[buyerId] = buyer
+	return buyer, nil
+}
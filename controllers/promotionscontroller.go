@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+// Promotions API
+type PromotionsController struct {
+	BaseController
+}
+
+func (c *PromotionsController) URLMapping() {
+	c.Mapping("CreatePromotion", c.CreatePromotion)
+	c.Mapping("GetPromotions", c.GetPromotions)
+}
+
+// @Title CreatePromotion
+// @Description Create promotion.
+// @Accept json
+// @Success 200 {object} models.Promotion
+// @router / [post]
+func (c *PromotionsController) CreatePromotion() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	promotion := new(models.Promotion)
+	promotion.Active = true
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, promotion)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Insert promotion.
+	dao := models.NewPromotionDao(customerId)
+	err = dao.Create(promotion)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = promotion
+	c.ServeJSON()
+}
+
+// @Title GetPromotion
+// @Description Get promotion.
+// @Param	promotion_id	path	uint64	true	"Promotion id."
+// @Success 200 {object} models.Promotion
+// @router /:promotion_id [get]
+func (c *PromotionsController) GetPromotion(promotion_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate promotion Id.
+	if promotion_id == nil {
+		err := fmt.Errorf("promotion_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Prepare query.
+	promotion := new(models.Promotion)
+	promotion.Id = *promotion_id
+
+	// Get the promotion.
+	err := models.Read(customerId, promotion)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = promotion
+	c.ServeJSON()
+}
+
+// @Title GetPromotions
+// @Description Get promotions.
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *PromotionsController) GetPromotions() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	promotions := make([]*models.Promotion, 0)
+	err := models.ReadAll(customerId, &promotions)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(promotions)
+	response["promotions"] = promotions
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title UpdatePromotion
+// @Description Update promotion.
+// @Accept json
+// @Param	promotion_id	path	uint64	true	"Promotion id."
+// @Success 200 {object} models.Promotion
+// @router /:promotion_id [patch]
+func (c *PromotionsController) UpdatePromotion(promotion_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate promotion Id.
+	if promotion_id == nil {
+		err := fmt.Errorf("promotion_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	promotion := new(models.Promotion)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, promotion)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	promotion.Id = *promotion_id
+
+	// Update the promotion.
+	dao := models.NewPromotionDao(customerId)
+	err = dao.Update(promotion)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = promotion
+	c.ServeJSON()
+}
+
+// @Title DeletePromotion
+// @Description Delete promotion.
+// @Param	promotion_id	path	uint64	true	"Promotion id."
+// @router /:promotion_id [delete]
+func (c *PromotionsController) DeletePromotion(promotion_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate promotion Id.
+	if promotion_id == nil {
+		err := fmt.Errorf("promotion_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Prepare query.
+	promotion := new(models.Promotion)
+	promotion.Id = *promotion_id
+
+	// Delete the promotion.
+	err := models.Delete(customerId, *promotion_id, promotion)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+}
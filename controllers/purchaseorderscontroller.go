@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+type ReceivePurchaseOrder struct {
+	UserId string                      `json:"user_id"`
+	Notes  string                      `json:"notes"`
+	Close  bool                        `json:"close"`
+	Lines  []*models.PurchaseOrderLine `json:"lines"`
+}
+
+// Purchase orders API
+type PurchaseOrdersController struct {
+	BaseController
+}
+
+func (c *PurchaseOrdersController) URLMapping() {
+	c.Mapping("CreatePurchaseOrder", c.CreatePurchaseOrder)
+}
+
+// @Title CreatePurchaseOrder
+// @Description Create a purchase order draft to a provider.
+// @Accept json
+// @Success 200 {object} models.PurchaseOrder
+// @router / [post]
+func (c *PurchaseOrdersController) CreatePurchaseOrder() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	order := new(models.PurchaseOrder)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, order)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create purchase order.
+	dao := models.NewPurchaseOrderDao(customerId)
+	err = dao.Create(order)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = order
+	c.ServeJSON()
+}
+
+// @Title GetPurchaseOrder
+// @Description Get purchase order with its lines.
+// @Param	order_id	path	uint64	true	"Purchase order id."
+// @Success 200 {object} models.PurchaseOrder
+// @router /:order_id [get]
+func (c *PurchaseOrdersController) GetPurchaseOrder(order_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate order Id.
+	if order_id == nil {
+		err := fmt.Errorf("order_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the purchase order.
+	dao := models.NewPurchaseOrderDao(customerId)
+	order, err := dao.Read(*order_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = order
+	c.ServeJSON()
+}
+
+// @Title GetPurchaseOrders
+// @Description Get purchase orders.
+// @Param provider_id query uint64 false "Provider id."
+// @Param headquarter_id query uint64 false "Headquarter id."
+// @Param status query string false "Purchase order status."
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *PurchaseOrdersController) GetPurchaseOrders(provider_id, headquarter_id uint64, status string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get purchase orders.
+	dao := models.NewPurchaseOrderDao(customerId)
+	orders, err := dao.FindByProviderAndHeadquarterAndStatus(provider_id, headquarter_id, status)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(orders)
+	response["orders"] = orders
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title SendPurchaseOrder
+// @Description Send the purchase order draft to the provider.
+// @Param	order_id	path	uint64	true	"Purchase order id."
+// @Param user_id query string true "User sending the purchase order."
+// @Success 200 {object} models.PurchaseOrder
+// @router /:order_id/send [patch]
+func (c *PurchaseOrdersController) SendPurchaseOrder(order_id *uint64, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate order Id.
+	if order_id == nil {
+		err := fmt.Errorf("order_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Send purchase order.
+	dao := models.NewPurchaseOrderDao(customerId)
+	order, err := dao.Send(*order_id, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = order
+	c.ServeJSON()
+}
+
+// @Title ReceivePurchaseOrder
+// @Description Receive purchase order lines recording them as caterings of
+// the purchase order headquarter.
+// @Accept json
+// @Param	order_id	path	uint64	true	"Purchase order id."
+// @Success 200 {object} models.PurchaseOrder
+// @router /:order_id/receive [patch]
+func (c *PurchaseOrdersController) ReceivePurchaseOrder(order_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate order Id.
+	if order_id == nil {
+		err := fmt.Errorf("order_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(ReceivePurchaseOrder)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(request.UserId) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Receive purchase order.
+	dao := models.NewPurchaseOrderDao(customerId)
+	order, err := dao.Receive(*order_id, request.Lines, request.UserId, request.Notes, request.Close)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = order
+	c.ServeJSON()
+}
+
+// @Title CancelPurchaseOrder
+// @Description Cancel the purchase order before it is received.
+// @Param	order_id	path	uint64	true	"Purchase order id."
+// @Param user_id query string true "User cancelling the purchase order."
+// @Success 200 {object} models.PurchaseOrder
+// @router /:order_id/cancel [patch]
+func (c *PurchaseOrdersController) CancelPurchaseOrder(order_id *uint64, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate order Id.
+	if order_id == nil {
+		err := fmt.Errorf("order_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Cancel purchase order.
+	dao := models.NewPurchaseOrderDao(customerId)
+	order, err := dao.Cancel(*order_id, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = order
+	c.ServeJSON()
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+// Replenishment API
+type ReplenishmentController struct {
+	BaseController
+}
+
+// @Title GetSuggestions
+// @Description Get the headquarter products to order from their sales
+// velocity, provider lead time and safety stock.
+// @Param headquarter_id query uint64 true "Headquarter id."
+// @Param window_days query int false "Days of sales to average."
+// @Success 200 {object} map[string]interface{}
+// @router /suggestions [get]
+func (c *ReplenishmentController) GetSuggestions(headquarter_id uint64, window_days int) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == 0 {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the suggestions.
+	dao := models.NewReplenishmentDao(customerId)
+	suggestions, err := dao.Suggest(headquarter_id, window_days)
+	c.serveModelError(err)
+
+	// Calculate the total cost.
+	var cost float64
+	for _, suggestion := range suggestions {
+		cost += float64(suggestion.Quantity) * suggestion.UnitCost
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(suggestions)
+	response["cost"] = cost
+	response["suggestions"] = suggestions
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title OrderSuggestions
+// @Description Convert the suggestions into purchase order drafts, one by
+// provider.
+// @Param headquarter_id query uint64 true "Headquarter id."
+// @Param provider_id query uint64 false "Provider id, every provider when empty."
+// @Param window_days query int false "Days of sales to average."
+// @Param user_id query string true "User ordering."
+// @Success 200 {object} map[string]interface{}
+// @router /orders [post]
+func (c *ReplenishmentController) OrderSuggestions(headquarter_id, provider_id uint64, window_days int, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate headquarter Id.
+	if headquarter_id == 0 {
+		err := fmt.Errorf("headquarter_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Order the suggestions.
+	dao := models.NewReplenishmentDao(customerId)
+	orders, err := dao.Order(headquarter_id, provider_id, window_days, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(orders)
+	response["orders"] = orders
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+type CloseShift struct {
+	CountedCash float64 `json:"counted_cash"`
+	Notes       string  `json:"notes"`
+}
+
+// Shifts API
+type ShiftsController struct {
+	BaseController
+}
+
+func (c *ShiftsController) URLMapping() {
+	c.Mapping("OpenShift", c.OpenShift)
+}
+
+// @Title OpenShift
+// @Description Open cash register shift.
+// @Accept json
+// @Success 200 {object} models.Shift
+// @router / [post]
+func (c *ShiftsController) OpenShift() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	shift := new(models.Shift)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, shift)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(shift.UserId) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Open shift.
+	dao := models.NewShiftDao(customerId)
+	err = dao.Open(shift)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = shift
+	c.ServeJSON()
+}
+
+// @Title GetShift
+// @Description Get shift with its expected vs counted cash report.
+// @Param	shift_id	path	uint64	true	"Shift id."
+// @Success 200 {object} models.ShiftReport
+// @router /:shift_id [get]
+func (c *ShiftsController) GetShift(shift_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate shift Id.
+	if shift_id == nil {
+		err := fmt.Errorf("shift_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the report.
+	dao := models.NewShiftDao(customerId)
+	report, err := dao.Report(*shift_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = report
+	c.ServeJSON()
+}
+
+// @Title GetShifts
+// @Description Get shifts.
+// @Param headquarter_id query uint64 false "Headquarter id."
+// @Param status query string false "Shift status."
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *ShiftsController) GetShifts(headquarter_id uint64, status string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get shifts.
+	dao := models.NewShiftDao(customerId)
+	shifts, err := dao.FindByHeadquarterAndStatus(headquarter_id, status)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(shifts)
+	response["shifts"] = shifts
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title AddMovement
+// @Description Register a cash drop or payout.
+// @Accept json
+// @Param	shift_id	path	uint64	true	"Shift id."
+// @Success 200 {object} models.CashMovement
+// @router /:shift_id/movements [post]
+func (c *ShiftsController) AddMovement(shift_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate shift Id.
+	if shift_id == nil {
+		err := fmt.Errorf("shift_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	movement := new(models.CashMovement)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, movement)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	movement.ShiftId = *shift_id
+
+	// Add movement.
+	dao := models.NewShiftDao(customerId)
+	err = dao.AddMovement(movement)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = movement
+	c.ServeJSON()
+}
+
+// @Title CloseShift
+// @Description Close shift with the counted cash.
+// @Accept json
+// @Param	shift_id	path	uint64	true	"Shift id."
+// @Success 200 {object} models.ShiftReport
+// @router /:shift_id/close [patch]
+func (c *ShiftsController) CloseShift(shift_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate shift Id.
+	if shift_id == nil {
+		err := fmt.Errorf("shift_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(CloseShift)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Close shift.
+	dao := models.NewShiftDao(customerId)
+	report, err := dao.Close(*shift_id, request.CountedCash, request.Notes)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = report
+	c.ServeJSON()
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+// Tax rates API
+type TaxRatesController struct {
+	BaseController
+}
+
+func (c *TaxRatesController) URLMapping() {
+	c.Mapping("CreateTaxRate", c.CreateTaxRate)
+	c.Mapping("GetTaxRates", c.GetTaxRates)
+}
+
+// @Title CreateTaxRate
+// @Description Create tax rate.
+// @Accept json
+// @Success 200 {object} models.TaxRate
+// @router / [post]
+func (c *TaxRatesController) CreateTaxRate() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	taxRate := new(models.TaxRate)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, taxRate)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Insert tax rate.
+	err = models.Insert(customerId, taxRate)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = taxRate
+	c.ServeJSON()
+}
+
+// @Title GetTaxRate
+// @Description Get tax rate.
+// @Param	tax_rate_id	path	uint64	true	"Tax rate id."
+// @Success 200 {object} models.TaxRate
+// @router /:tax_rate_id [get]
+func (c *TaxRatesController) GetTaxRate(tax_rate_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate tax rate Id.
+	if tax_rate_id == nil {
+		err := fmt.Errorf("tax_rate_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Prepare query.
+	taxRate := new(models.TaxRate)
+	taxRate.Id = *tax_rate_id
+
+	// Get the tax rate.
+	err := models.Read(customerId, taxRate)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	c.Data["json"] = taxRate
+	c.ServeJSON()
+}
+
+// @Title GetTaxRates
+// @Description Get tax rates.
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *TaxRatesController) GetTaxRates() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	taxRates := make([]*models.TaxRate, 0)
+	err := models.ReadAll(customerId, &taxRates)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(taxRates)
+	response["tax_rates"] = taxRates
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title UpdateTaxRate
+// @Description Update tax rate.
+// @Accept json
+// @Param	tax_rate_id	path	uint64	true	"Tax rate id."
+// @Success 200 {object} models.TaxRate
+// @router /:tax_rate_id [patch]
+func (c *TaxRatesController) UpdateTaxRate(tax_rate_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate tax rate Id.
+	if tax_rate_id == nil {
+		err := fmt.Errorf("tax_rate_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	taxRate := new(models.TaxRate)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, taxRate)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+	taxRate.Id = *tax_rate_id
+
+	// Update the tax rate.
+	dao := models.NewTaxRateDao(customerId)
+	err = dao.Update(taxRate)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = taxRate
+	c.ServeJSON()
+}
+
+// @Title DeleteTaxRate
+// @Description Delete tax rate, the rates used by products can not be
+// deleted.
+// @Param	tax_rate_id	path	uint64	true	"Tax rate id."
+// @router /:tax_rate_id [delete]
+func (c *TaxRatesController) DeleteTaxRate(tax_rate_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate tax rate Id.
+	if tax_rate_id == nil {
+		err := fmt.Errorf("tax_rate_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Delete the tax rate.
+	dao := models.NewTaxRateDao(customerId)
+	err := dao.Delete(*tax_rate_id)
+	c.serveModelError(err)
+}
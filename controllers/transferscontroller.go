@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"app-rest-inventory/models"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+)
+
+type ReceiveTransfer struct {
+	UserId string                 `json:"user_id"`
+	Notes  string                 `json:"notes"`
+	Close  bool                   `json:"close"`
+	Lines  []*models.TransferLine `json:"lines"`
+}
+
+// Transfers API
+type TransfersController struct {
+	BaseController
+}
+
+func (c *TransfersController) URLMapping() {
+	c.Mapping("CreateTransfer", c.CreateTransfer)
+}
+
+// @Title CreateTransfer
+// @Description Create a transfer draft between headquarters.
+// @Accept json
+// @Success 200 {object} models.Transfer
+// @router / [post]
+func (c *TransfersController) CreateTransfer() {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Unmarshall request.
+	transfer := new(models.Transfer)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, transfer)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create transfer.
+	dao := models.NewTransferDao(customerId)
+	err = dao.Create(transfer)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = transfer
+	c.ServeJSON()
+}
+
+// @Title GetTransfer
+// @Description Get transfer with its lines.
+// @Param	transfer_id	path	uint64	true	"Transfer id."
+// @Success 200 {object} models.Transfer
+// @router /:transfer_id [get]
+func (c *TransfersController) GetTransfer(transfer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate transfer Id.
+	if transfer_id == nil {
+		err := fmt.Errorf("transfer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get the transfer.
+	dao := models.NewTransferDao(customerId)
+	transfer, err := dao.Read(*transfer_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = transfer
+	c.ServeJSON()
+}
+
+// @Title GetTransfers
+// @Description Get transfers.
+// @Param headquarter_id query uint64 false "Origin or destination headquarter id."
+// @Param status query string false "Transfer status."
+// @Success 200 {object} map[string]interface{}
+// @router / [get]
+func (c *TransfersController) GetTransfers(headquarter_id uint64, status string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get transfers.
+	dao := models.NewTransferDao(customerId)
+	transfers, err := dao.FindByHeadquarterAndStatus(headquarter_id, status)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(transfers)
+	response["transfers"] = transfers
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title GetInTransit
+// @Description Get the dispatched stock not received yet.
+// @Param headquarter_id query uint64 false "Destination headquarter id."
+// @Success 200 {object} map[string]interface{}
+// @router /in-transit [get]
+func (c *TransfersController) GetInTransit(headquarter_id uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Get the stock in transit.
+	dao := models.NewTransferDao(customerId)
+	stock, err := dao.InTransit(headquarter_id)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Serve JSON.
+	response := make(map[string]interface{})
+	response["total"] = len(stock)
+	response["products"] = stock
+
+	c.Data["json"] = response
+	c.ServeJSON()
+}
+
+// @Title DispatchTransfer
+// @Description Dispatch the transfer decreasing the origin headquarter stock.
+// @Param	transfer_id	path	uint64	true	"Transfer id."
+// @Param user_id query string true "User dispatching the transfer."
+// @Success 200 {object} models.Transfer
+// @router /:transfer_id/dispatch [patch]
+func (c *TransfersController) DispatchTransfer(transfer_id *uint64, user_id string) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate transfer Id.
+	if transfer_id == nil {
+		err := fmt.Errorf("transfer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(user_id) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Dispatch transfer.
+	dao := models.NewTransferDao(customerId)
+	transfer, err := dao.Dispatch(*transfer_id, user_id)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = transfer
+	c.ServeJSON()
+}
+
+// @Title ReceiveTransfer
+// @Description Receive transfer lines increasing the destination headquarter
+// stock. Closing the transfer keeps the missing amounts as discrepancies.
+// @Accept json
+// @Param	transfer_id	path	uint64	true	"Transfer id."
+// @Success 200 {object} models.Transfer
+// @router /:transfer_id/receive [patch]
+func (c *TransfersController) ReceiveTransfer(transfer_id *uint64) {
+	// Get customer Id from the cookies.
+	customerId := c.Ctx.GetCookie("customer_id")
+	if len(customerId) == 0 {
+		err := fmt.Errorf("customer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Validate transfer Id.
+	if transfer_id == nil {
+		err := fmt.Errorf("transfer_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Unmarshall request.
+	request := new(ReceiveTransfer)
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, request)
+	if err != nil {
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Validate user Id.
+	if len(request.UserId) == 0 {
+		err := fmt.Errorf("user_id can not be empty.")
+		logs.Error(err.Error())
+		c.serveError(http.StatusBadRequest, err.Error())
+	}
+
+	// Receive transfer.
+	dao := models.NewTransferDao(customerId)
+	transfer, err := dao.Receive(*transfer_id, request.Lines, request.UserId, request.Notes, request.Close)
+	c.serveModelError(err)
+
+	// Serve JSON.
+	c.Data["json"] = transfer
+	c.ServeJSON()
+}
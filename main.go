@@ -2,11 +2,13 @@ package main
 
 import (
 	"app-rest-inventory/controllers"
+	"app-rest-inventory/models"
 	_ "app-rest-inventory/routers"
 	"github.com/astaxie/beego"
 	"github.com/astaxie/beego/logs"
 	"github.com/astaxie/beego/plugins/cors"
 	"runtime"
+	"time"
 )
 
 func main() {
@@ -24,6 +26,12 @@ func main() {
 	// Setup error handler.
 	setupErrorHandler()
 
+	// Expire the held bills.
+	go models.ExpireHolds(time.Minute)
+
+	// Deliver the low stock alerts.
+	go models.NotifyStockAlerts(time.Minute)
+
 	// Run and serve.
 	logs.Info("The app.rest is set up correctly.")
 	logs.Info("Listen and serve at %s", beego.AppConfig.String("httpport"))
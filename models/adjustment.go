@@ -0,0 +1,299 @@
+package models
+
+import (
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"github.com/go-xorm/xorm"
+	"math"
+	"time"
+)
+
+var (
+	StockAdjustmentTableName = "stock_adjustment"
+
+	// Adjustments worth more than the threshold, at the product cost, wait for
+	// the approval of an admin. Every adjustment waits for the approval when
+	// the threshold is 0 or not configured.
+	AdjustmentApprovalThreshold float64
+)
+
+// Stock adjustment reasons.
+const (
+	AdjustmentReasonDamage          = "damage"
+	AdjustmentReasonTheft           = "theft"
+	AdjustmentReasonExpiry          = "expiry"
+	AdjustmentReasonCountCorrection = "count_correction"
+)
+
+// Stock adjustment status.
+const (
+	AdjustmentStatusPending  = "pending"
+	AdjustmentStatusApplied  = "applied"
+	AdjustmentStatusRejected = "rejected"
+)
+
+// Init adjustments configuration.
+func init() {
+	val, err := beego.AppConfig.Float("adjustments::approvalthreshold")
+	if err != nil {
+		logs.Error(err.Error())
+		val = 0
+	}
+	AdjustmentApprovalThreshold = val
+}
+
+// @Description Manual change of a headquarter product stock. Negative deltas
+// are losses.
+type StockAdjustment struct {
+	Id            uint64    `xorm:"pk autoincr" json:"id"`
+	HeadquarterId uint64    `xorm:"index" json:"headquarter_id"`
+	ProductId     uint64    `xorm:"index" json:"product_id"`
+	Delta         int64     `xorm:"not null" json:"delta"`
+	Reason        string    `xorm:"index not null" json:"reason"`
+	Notes         string    `json:"notes"`
+	UnitCost      float64   `xorm:"not null default 0" json:"unit_cost"`
+	Value         float64   `xorm:"not null default 0" json:"value"`
+	Status        string    `xorm:"index not null" json:"status"`
+	UserId        string    `json:"user_id"`
+	ReviewedBy    string    `json:"reviewed_by"`
+	Reviewed      time.Time `xorm:"null" json:"reviewed"`
+	Created       time.Time `xorm:"created" json:"created"`
+	Updated       time.Time `xorm:"updated" json:"updated"`
+}
+
+func (s *StockAdjustment) TableName() string {
+	return StockAdjustmentTableName
+}
+
+// @Description Validate the adjustment reason and delta.
+func (s *StockAdjustment) Validate() error {
+	switch s.Reason {
+	case AdjustmentReasonDamage, AdjustmentReasonTheft, AdjustmentReasonExpiry, AdjustmentReasonCountCorrection:
+	default:
+		return &ValidationError{Message: fmt.Sprintf("Invalid adjustment reason %s.", s.Reason)}
+	}
+	if s.Delta == 0 {
+		return &ValidationError{Message: "The adjustment delta can not be 0."}
+	}
+
+	return nil
+}
+
+// @Description Lost units and their value at cost by reason.
+type AdjustmentLoss struct {
+	Reason string  `json:"reason"`
+	Units  uint64  `json:"units"`
+	Value  float64 `json:"value"`
+}
+
+type StockAdjustmentDao struct {
+	Dao
+}
+
+func NewStockAdjustmentDao(schema string) *StockAdjustmentDao {
+	d := new(StockAdjustmentDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Create the adjustment. It is applied to the stock unless its
+// value exceeds the approval threshold, then it waits for an admin.
+// @Param adjustment Stock adjustment.
+func (d *StockAdjustmentDao) Create(adjustment *StockAdjustment) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		return d.create(session, adjustment, false)
+	})
+}
+
+// @Description Approve the pending adjustment applying it to the stock.
+// @Param adjustmentId Stock adjustment Id.
+// @Param userId Admin approving the adjustment.
+func (d *StockAdjustmentDao) Approve(adjustmentId uint64, userId string) (*StockAdjustment, error) {
+	var adjustment *StockAdjustment
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		adjustment, err = d.readPendingForUpdate(session, adjustmentId)
+		if err != nil {
+			return err
+		}
+
+		err = d.apply(session, adjustment)
+		if err != nil {
+			return err
+		}
+
+		adjustment.Status = AdjustmentStatusApplied
+		adjustment.ReviewedBy = userId
+		adjustment.Reviewed = time.Now()
+		_, err = session.ID(adjustment.Id).Cols("status", "reviewed_by", "reviewed").Update(adjustment)
+
+		return err
+	})
+
+	return adjustment, err
+}
+
+// @Description Reject the pending adjustment, the stock is not changed.
+// @Param adjustmentId Stock adjustment Id.
+// @Param userId Admin rejecting the adjustment.
+func (d *StockAdjustmentDao) Reject(adjustmentId uint64, userId string) (*StockAdjustment, error) {
+	var adjustment *StockAdjustment
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		adjustment, err = d.readPendingForUpdate(session, adjustmentId)
+		if err != nil {
+			return err
+		}
+
+		adjustment.Status = AdjustmentStatusRejected
+		adjustment.ReviewedBy = userId
+		adjustment.Reviewed = time.Now()
+		_, err = session.ID(adjustment.Id).Cols("status", "reviewed_by", "reviewed").Update(adjustment)
+
+		return err
+	})
+
+	return adjustment, err
+}
+
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+// @Param status Adjustment status, empty for every status.
+func (d *StockAdjustmentDao) FindByHeadquarterAndStatus(headquarterId uint64, status string) ([]*StockAdjustment, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Desc("id")
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+	if len(status) > 0 {
+		session = session.And("status = ?", status)
+	}
+
+	adjustments := make([]*StockAdjustment, 0)
+	err := session.Find(&adjustments)
+
+	return adjustments, err
+}
+
+// @Description Get the applied losses by reason.
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+// @Param start Start date, zero for no start.
+// @Param end End date, zero for no end.
+func (d *StockAdjustmentDao) LossesByReason(headquarterId uint64, start, end time.Time) ([]*AdjustmentLoss, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Where("status = ? AND delta < 0", AdjustmentStatusApplied)
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+	if !start.IsZero() {
+		session = session.And("created >= ?", start)
+	}
+	if !end.IsZero() {
+		session = session.And("created <= ?", end)
+	}
+
+	adjustments := make([]*StockAdjustment, 0)
+	err := session.Find(&adjustments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Golang is faster than PostgreSQL SGBD so here we group by reason.
+	losses := make([]*AdjustmentLoss, 0)
+	byReason := make(map[string]*AdjustmentLoss)
+	for _, adjustment := range adjustments {
+		loss, ok := byReason[adjustment.Reason]
+		if !ok {
+			loss = &AdjustmentLoss{Reason: adjustment.Reason}
+			byReason[adjustment.Reason] = loss
+			losses = append(losses, loss)
+		}
+		loss.Units += uint64(-adjustment.Delta)
+		loss.Value -= adjustment.Value
+	}
+
+	return losses, nil
+}
+
+// @Description Insert the adjustment valued at the product cost and apply
+// it, approved adjustments skip the approval threshold.
+// @Param session Transaction session.
+// @Param adjustment Stock adjustment.
+// @Param approved Whether the adjustment is already approved.
+func (d *StockAdjustmentDao) create(session *xorm.Session, adjustment *StockAdjustment, approved bool) error {
+	err := adjustment.Validate()
+	if err != nil {
+		return err
+	}
+
+	// Value the adjustment.
+	product := new(Product)
+	found, err := session.NoCache().ID(adjustment.ProductId).Get(product)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", adjustment.ProductId)}
+	}
+	adjustment.UnitCost = product.Cost
+	adjustment.Value = float64(adjustment.Delta) * product.Cost
+
+	// Insert adjustment.
+	adjustment.Status = AdjustmentStatusApplied
+	if !approved && (AdjustmentApprovalThreshold <= 0 || math.Abs(adjustment.Value) > AdjustmentApprovalThreshold) {
+		adjustment.Status = AdjustmentStatusPending
+	}
+	_, err = session.Insert(adjustment)
+	if err != nil {
+		return err
+	}
+
+	if adjustment.Status == AdjustmentStatusPending {
+		return nil
+	}
+
+	return d.apply(session, adjustment)
+}
+
+// @Description Apply the adjustment delta to the headquarter stock.
+// @Param session Transaction session.
+// @Param adjustment Stock adjustment.
+func (d *StockAdjustmentDao) apply(session *xorm.Session, adjustment *StockAdjustment) error {
+	headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+	if adjustment.Delta > 0 {
+		return headquarterProductDao.IncreaseStock(session, adjustment.HeadquarterId, adjustment.ProductId, uint64(adjustment.Delta), StockReasonAdjustment, adjustment.Id, adjustment.UserId)
+	}
+
+	err := headquarterProductDao.DecreaseStock(session, adjustment.HeadquarterId, adjustment.ProductId, uint64(-adjustment.Delta), StockReasonAdjustment, adjustment.Id, adjustment.UserId)
+	if stockError, ok := err.(*StockError); ok {
+		return StockErrors{stockError}
+	}
+
+	return err
+}
+
+// @Description Lock the adjustment row and validate it is pending.
+// @Param session Transaction session.
+// @Param adjustmentId Stock adjustment Id.
+func (d *StockAdjustmentDao) readPendingForUpdate(session *xorm.Session, adjustmentId uint64) (*StockAdjustment, error) {
+	adjustment := new(StockAdjustment)
+	found, err := session.NoCache().ForUpdate().ID(adjustmentId).Get(adjustment)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Stock adjustment %d does not exist.", adjustmentId)}
+	}
+	if adjustment.Status != AdjustmentStatusPending {
+		return nil, &ConflictError{Message: fmt.Sprintf("Stock adjustment %d is %s.", adjustmentId, adjustment.Status)}
+	}
+
+	return adjustment, nil
+}
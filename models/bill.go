@@ -1,22 +1,840 @@
 package models
 
 import (
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"github.com/go-xorm/xorm"
+	"math"
+	"strconv"
 	"time"
 )
 
 var (
 	BillTableName = "bill"
+
+	// Minutes a held bill keeps its stock reserved.
+	HoldMinutes int
+)
+
+// Bill status.
+const (
+	BillStatusIssued  = "issued"
+	BillStatusVoided  = "voided"
+	BillStatusHeld    = "held"
+	BillStatusExpired = "expired"
 )
 
+// Init bills configuration.
+func init() {
+	val, err := beego.AppConfig.Int("bills::holdminutes")
+	if err != nil {
+		logs.Error(err.Error())
+		val = 30
+	}
+	HoldMinutes = val
+}
+
 type Bill struct {
-	Id            uint64    `xorm:"pk autoincr" json:"id"`
-	HeadquarterId uint64    `xorm:"index" json:"headquarter_id"`
-	UserId        string    `xorm:"index" json:"user_id"`
-	Discount      float64   `xorm:"not null" json:"discount"`
-	Created       time.Time `xorm:"created" json:"created"`
-	Updated       time.Time `xorm:"updated" json:"updated"`
+	Id                   uint64    `xorm:"pk autoincr" json:"id"`
+	HeadquarterId        uint64    `xorm:"index unique(bill_number)" json:"headquarter_id"`
+	Prefix               string    `json:"prefix"`
+	Number               uint64    `xorm:"null unique(bill_number)" json:"number"`
+	UserId               string    `xorm:"index" json:"user_id"`
+	BuyerId              uint64    `xorm:"index not null default 0" json:"buyer_id"`
+	ShiftId              uint64    `xorm:"index" json:"shift_id"`
+	Discount             float64   `xorm:"not null" json:"discount"`
+	DiscountType         string    `xorm:"not null default 'fixed'" json:"discount_type"`
+	DiscountRate         float64   `xorm:"not null default 0" json:"discount_rate"`
+	DiscountAuthorizedBy string    `json:"discount_authorized_by"`
+	CouponId             uint64    `xorm:"not null default 0" json:"coupon_id"`
+	CouponCode           string    `json:"coupon_code"`
+	CouponType           string    `json:"coupon_type"`
+	CouponRate           float64   `xorm:"not null default 0" json:"coupon_rate"`
+	CouponAmount         float64   `xorm:"not null default 0" json:"coupon_amount"`
+	CouponDiscount       float64   `xorm:"not null default 0" json:"coupon_discount"`
+	CouponBuyer          string    `xorm:"-" json:"-"`
+	Subtotal             float64   `xorm:"not null default 0" json:"subtotal"`
+	Tax                  float64   `xorm:"not null default 0" json:"tax"`
+	Total                float64   `xorm:"not null default 0" json:"total"`
+	Status               string    `xorm:"index not null default 'issued'" json:"status"`
+	VoidReason           string    `json:"void_reason"`
+	VoidedBy             string    `json:"voided_by"`
+	Voided               time.Time `xorm:"null" json:"voided"`
+	HoldExpires          time.Time `xorm:"null" json:"hold_expires"`
+	Created              time.Time `xorm:"created" json:"created"`
+	Updated              time.Time `xorm:"updated" json:"updated"`
 }
 
 func (b *Bill) TableName() string {
 	return BillTableName
 }
+
+// @Description Bill discount plus the coupon discount.
+func (b *Bill) totalDiscount() float64 {
+	return b.Discount + b.CouponDiscount
+}
+
+// @Description Copy the coupon and its discount terms to the bill, so later
+// edits of the bill keep the terms it was redeemed with.
+// @Param coupon Coupon.
+func (b *Bill) snapshotCoupon(coupon *Coupon) {
+	b.CouponId = coupon.Id
+	b.CouponCode = coupon.Code
+	b.CouponType = coupon.DiscountType
+	if len(b.CouponType) == 0 {
+		b.CouponType = DiscountFixed
+	}
+	b.CouponRate = coupon.DiscountRate
+	b.CouponAmount = coupon.Discount
+}
+
+// @Description Bill number with the headquarter prefix.
+func (b *Bill) Code() string {
+	return fmt.Sprintf("%s%08d", b.Prefix, b.Number)
+}
+
+type BillDao struct {
+	Dao
+}
+
+func NewBillDao(schema string) *BillDao {
+	d := new(BillDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Create the bill, its sales and payments and decrease the
+// headquarter stock in a single transaction. Nothing is persisted when a sale
+// line fails, if the failure is due to the stock a StockErrors with every
+// failed line is returned.
+// @Param bill Bill.
+// @Param sales Bill sales.
+// @Param payments Bill payments.
+func (d *BillDao) Create(bill *Bill, sales []*Sale, payments []*Payment) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		return d.issue(session, bill, sales, payments)
+	})
+}
+
+// @Description Issue the bill with its sales and payments. Held bills, the
+// ones with an Id, are updated instead of inserted.
+// @Param session Transaction session.
+// @Param bill Bill.
+// @Param sales Bill sales.
+// @Param payments Bill payments.
+func (d *BillDao) issue(session *xorm.Session, bill *Bill, sales []*Sale, payments []*Payment) error {
+	// Assign the bill number.
+	err := nextBillNumber(session, bill)
+	if err != nil {
+		return err
+	}
+
+	// Validate the buyer.
+	if bill.BuyerId > 0 {
+		err = NewBuyerDao(d.GetSchema()).validateExists(session, bill.BuyerId)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Attach the bill to the seller open shift.
+	shift, err := NewShiftDao(d.GetSchema()).FindOpen(session, bill.HeadquarterId, bill.UserId)
+	if err != nil {
+		return err
+	}
+	if shift == nil {
+		return &ConflictError{Message: fmt.Sprintf("User %s does not have an open shift in headquarter %d.", bill.UserId, bill.HeadquarterId)}
+	}
+
+	// Insert bill.
+	bill.ShiftId = shift.Id
+	bill.Status = BillStatusIssued
+	if len(bill.DiscountType) == 0 {
+		bill.DiscountType = DiscountFixed
+	}
+	if bill.Id == 0 {
+		_, err = session.Insert(bill)
+	} else {
+		bill.Created = time.Now()
+		_, err = session.ID(bill.Id).Cols("prefix", "number", "user_id", "buyer_id", "shift_id", "discount", "discount_type", "discount_rate", "discount_authorized_by", "coupon_id", "coupon_code", "status", "created").Update(bill)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Decrease the stock.
+	stockErrors := make(StockErrors, 0)
+	headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+	for i, sale := range sales {
+		// Decrease the current product existences.
+		err = headquarterProductDao.DecreaseStock(session, bill.HeadquarterId, sale.ProductId, sale.Amount, StockReasonSale, bill.Id, bill.UserId)
+		if stockError, ok := err.(*StockError); ok {
+			stockError.Line = i
+			stockErrors = append(stockErrors, stockError)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// Snapshot the product price and cost.
+		err = snapshotProduct(session, sale)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(stockErrors) > 0 {
+		return stockErrors
+	}
+
+	// Apply the running promotions over every line.
+	err = applyPromotions(session, d.GetSchema(), bill.HeadquarterId, sales, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Insert sales.
+	for _, sale := range sales {
+		// Resolve the line discount.
+		err = sale.applyDiscount()
+		if err != nil {
+			return err
+		}
+
+		// Insert sale.
+		sale.BillId = bill.Id
+		_, err = session.Insert(sale)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Redeem the coupon, the buyer uses are counted by buyer Id when
+	// the bill has a buyer.
+	if len(bill.CouponCode) > 0 {
+		buyer := bill.CouponBuyer
+		if bill.BuyerId > 0 {
+			buyer = strconv.FormatUint(bill.BuyerId, 10)
+		}
+		_, err = NewCouponDao(d.GetSchema()).redeem(session, bill, buyer)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = d.updateTotal(session, bill)
+	if err != nil {
+		return err
+	}
+
+	// Validate the payments cover the total.
+	err = settlePayments(payments, bill.Total)
+	if err != nil {
+		return err
+	}
+
+	// Insert payments.
+	loyaltyDao := NewLoyaltyDao(d.GetSchema())
+	for _, payment := range payments {
+		payment.BillId = bill.Id
+		_, err = session.Insert(payment)
+		if err != nil {
+			return err
+		}
+
+		// Redeem the paid points.
+		if payment.Method == PaymentMethodLoyalty {
+			err = loyaltyDao.redeem(session, bill, payment)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Earn the buyer points.
+	return loyaltyDao.earn(session, bill, payments)
+}
+
+// @Description Price the bill and its sales with the current prices,
+// promotions and discounts without persisting them.
+// @Param bill Bill.
+// @Param sales Bill sales.
+func (d *BillDao) Preview(bill *Bill, sales []*Sale) error {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	for _, sale := range sales {
+		// Snapshot the product price and cost.
+		err := snapshotProduct(session, sale)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Apply the running promotions.
+	err := applyPromotions(session, d.GetSchema(), bill.HeadquarterId, sales, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Resolve the line discounts.
+	for _, sale := range sales {
+		err = sale.applyDiscount()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(bill.DiscountType) == 0 {
+		bill.DiscountType = DiscountFixed
+	}
+
+	// Validate the coupon without redeeming it.
+	if len(bill.CouponCode) > 0 {
+		coupon, err := NewCouponDao(d.GetSchema()).readByCode(session, bill.CouponCode)
+		if err != nil {
+			return err
+		}
+		err = coupon.validateFor(bill.HeadquarterId, time.Now())
+		if err != nil {
+			return err
+		}
+		bill.snapshotCoupon(coupon)
+	}
+
+	return bill.applyTotals(sales)
+}
+
+// @Description Find bills by number.
+// @Param number Bill number.
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+func (d *BillDao) FindByNumber(number uint64, headquarterId uint64) ([]*Bill, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Where("number = ?", number)
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+
+	bills := make([]*Bill, 0)
+	err := session.Asc("headquarter_id").Find(&bills)
+
+	return bills, err
+}
+
+// @Description Lock the bill row until the transaction ends.
+// @Param session Transaction session.
+// @Param billId Bill Id.
+func (d *BillDao) ReadForUpdate(session *xorm.Session, billId uint64) (*Bill, error) {
+	bill := new(Bill)
+	found, err := session.NoCache().ForUpdate().ID(billId).Get(bill)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Bill %d does not exist.", billId)}
+	}
+
+	return bill, nil
+}
+
+// @Description Lock the bill row and validate it can still be modified.
+// @Param session Transaction session.
+// @Param billId Bill Id.
+func (d *BillDao) readIssuedForUpdate(session *xorm.Session, billId uint64) (*Bill, error) {
+	bill, err := d.ReadForUpdate(session, billId)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != BillStatusIssued {
+		return nil, &ConflictError{Message: fmt.Sprintf("Bill %d is %s.", billId, bill.Status)}
+	}
+
+	return bill, nil
+}
+
+// @Description Add a sale line to an existing bill. When the sale Id matches
+// a line of the bill its amount is replaced and the stock is adjusted by the
+// difference, the line product can not be changed. Otherwise a new line is
+// inserted.
+// @Param billId Bill Id.
+// @Param sale Sale.
+func (d *BillDao) AddSale(billId uint64, sale *Sale, authorizedBy string) (*Bill, error) {
+	var bill *Bill
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		bill, err = d.readIssuedForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+		if len(authorizedBy) > 0 {
+			bill.DiscountAuthorizedBy = authorizedBy
+		}
+
+		// Validate the amount, lines are removed with RemoveSale.
+		if sale.Amount == 0 {
+			return &ValidationError{Message: "The sale amount must be greater than zero."}
+		}
+
+		// Look for the current line.
+		current := new(Sale)
+		found := false
+		if sale.Id > 0 {
+			found, err = session.NoCache().Where("id = ? AND bill_id = ?", sale.Id, billId).Get(current)
+			if err != nil {
+				return err
+			}
+		}
+
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		if found {
+			// The line product can not be changed.
+			if sale.ProductId > 0 && sale.ProductId != current.ProductId {
+				return &ValidationError{Message: fmt.Sprintf("Sale %d is product %d, the product can not be changed.", current.Id, current.ProductId)}
+			}
+			err = d.validateNotReturned(session, current)
+			if err != nil {
+				return err
+			}
+
+			// Adjust the stock by the difference.
+			if sale.Amount > current.Amount {
+				err = headquarterProductDao.DecreaseStock(session, bill.HeadquarterId, current.ProductId, sale.Amount-current.Amount, StockReasonSale, bill.Id, bill.UserId)
+			} else {
+				err = headquarterProductDao.IncreaseStock(session, bill.HeadquarterId, current.ProductId, current.Amount-sale.Amount, StockReasonSale, bill.Id, bill.UserId)
+			}
+			if stockError, ok := err.(*StockError); ok {
+				return StockErrors{stockError}
+			}
+			if err != nil {
+				return err
+			}
+
+			// Update sale.
+			current.Amount = sale.Amount
+			current.Discount = sale.Discount
+			current.DiscountType = sale.DiscountType
+			current.DiscountRate = sale.DiscountRate
+			if len(current.DiscountType) == 0 {
+				current.DiscountType = DiscountFixed
+			}
+			_, err = session.ID(current.Id).Cols("amount", "discount", "discount_type", "discount_rate").Update(current)
+			if err != nil {
+				return err
+			}
+			*sale = *current
+		} else {
+			// Validate the product.
+			if sale.ProductId == 0 {
+				return &ValidationError{Message: "The sale product can not be empty."}
+			}
+			var exists bool
+			exists, err = session.NoCache().ID(sale.ProductId).Exist(new(Product))
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return &ValidationError{Message: fmt.Sprintf("Product %d does not exist.", sale.ProductId)}
+			}
+
+			// Decrease the current product existences.
+			err = headquarterProductDao.DecreaseStock(session, bill.HeadquarterId, sale.ProductId, sale.Amount, StockReasonSale, bill.Id, bill.UserId)
+			if stockError, ok := err.(*StockError); ok {
+				return StockErrors{stockError}
+			}
+			if err != nil {
+				return err
+			}
+
+			// Snapshot the product price and cost.
+			err = snapshotProduct(session, sale)
+			if err != nil {
+				return err
+			}
+
+			// Insert sale, the discount is resolved with the promotions.
+			if len(sale.DiscountType) == 0 {
+				sale.DiscountType = DiscountFixed
+			}
+			sale.Id = 0
+			sale.BillId = billId
+			_, err = session.Insert(sale)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = d.reapplyPromotions(session, bill)
+		if err != nil {
+			return err
+		}
+
+		err = d.updateTotal(session, bill)
+		if err != nil {
+			return err
+		}
+
+		// Settle the payments and the buyer points with the new total.
+		err = NewPaymentDao(d.GetSchema()).resettle(session, bill)
+		if err != nil {
+			return err
+		}
+		return NewLoyaltyDao(d.GetSchema()).reconcile(session, bill, 0)
+	})
+
+	return bill, err
+}
+
+// @Description Remove a sale line from an existing bill restoring its stock.
+// @Param billId Bill Id.
+// @Param saleId Sale Id.
+func (d *BillDao) RemoveSale(billId, saleId uint64) (*Bill, error) {
+	var bill *Bill
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		bill, err = d.readIssuedForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Get the line.
+		sale := new(Sale)
+		found, err := session.NoCache().Where("id = ? AND bill_id = ?", saleId, billId).Get(sale)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Sale %d does not exist in bill %d.", saleId, billId)}
+		}
+		err = d.validateNotReturned(session, sale)
+		if err != nil {
+			return err
+		}
+
+		// Restore the stock.
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		err = headquarterProductDao.IncreaseStock(session, bill.HeadquarterId, sale.ProductId, sale.Amount, StockReasonSale, bill.Id, bill.UserId)
+		if err != nil {
+			return err
+		}
+
+		// Delete sale.
+		_, err = session.ID(sale.Id).Delete(new(Sale))
+		if err != nil {
+			return err
+		}
+
+		err = d.reapplyPromotions(session, bill)
+		if err != nil {
+			return err
+		}
+
+		err = d.updateTotal(session, bill)
+		if err != nil {
+			return err
+		}
+
+		// Settle the payments and the buyer points with the new total.
+		err = NewPaymentDao(d.GetSchema()).resettle(session, bill)
+		if err != nil {
+			return err
+		}
+		return NewLoyaltyDao(d.GetSchema()).reconcile(session, bill, 0)
+	})
+
+	return bill, err
+}
+
+// @Description Update the bill discount and its total. The discount
+// authorization is kept unless a new one is given.
+// @Param billId Bill Id.
+// @Param discount Bill with the discount type, rate, amount and authorization.
+func (d *BillDao) UpdateDiscount(billId uint64, discount *Bill) (*Bill, error) {
+	var bill *Bill
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		bill, err = d.readIssuedForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Update discount.
+		bill.Discount = discount.Discount
+		bill.DiscountType = discount.DiscountType
+		bill.DiscountRate = discount.DiscountRate
+		if len(bill.DiscountType) == 0 {
+			bill.DiscountType = DiscountFixed
+		}
+		if len(discount.DiscountAuthorizedBy) > 0 {
+			bill.DiscountAuthorizedBy = discount.DiscountAuthorizedBy
+		}
+		_, err = session.ID(bill.Id).Cols("discount_type", "discount_rate", "discount_authorized_by").Update(bill)
+		if err != nil {
+			return err
+		}
+
+		err = d.updateTotal(session, bill)
+		if err != nil {
+			return err
+		}
+
+		// Settle the payments and the buyer points with the new total.
+		err = NewPaymentDao(d.GetSchema()).resettle(session, bill)
+		if err != nil {
+			return err
+		}
+		return NewLoyaltyDao(d.GetSchema()).reconcile(session, bill, 0)
+	})
+
+	return bill, err
+}
+
+// @Description Void the bill restoring the stock of every sale to the bill
+// headquarter. Voided bills keep their sales but are excluded from revenue.
+// @Param billId Bill Id.
+// @Param userId User voiding the bill.
+// @Param reason Void reason.
+func (d *BillDao) Void(billId uint64, userId, reason string) (*Bill, error) {
+	var bill *Bill
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		bill, err = d.readIssuedForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Get the sales.
+		sales := make([]*Sale, 0)
+		err = session.NoCache().Where("bill_id = ?", billId).Find(&sales)
+		if err != nil {
+			return err
+		}
+
+		// Get the returned amounts, their stock is already restored.
+		returned, err := NewCreditNoteDao(d.GetSchema()).ReturnedAmounts(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Restore the stock.
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		for _, sale := range sales {
+			amount := sale.Amount - returned[sale.Id]
+			if amount == 0 {
+				continue
+			}
+			err = headquarterProductDao.IncreaseStock(session, bill.HeadquarterId, sale.ProductId, amount, StockReasonVoid, bill.Id, userId)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Give the coupon use back.
+		err = NewCouponDao(d.GetSchema()).release(session, bill)
+		if err != nil {
+			return err
+		}
+
+		// Reverse the buyer points.
+		err = NewLoyaltyDao(d.GetSchema()).reverseBill(session, bill)
+		if err != nil {
+			return err
+		}
+
+		// Void the bill.
+		bill.Status = BillStatusVoided
+		bill.VoidReason = reason
+		bill.VoidedBy = userId
+		bill.Voided = time.Now()
+		_, err = session.ID(bill.Id).Cols("status", "void_reason", "voided_by", "voided").Update(bill)
+
+		return err
+	})
+
+	return bill, err
+}
+
+// @Description Validate the sale has not been returned.
+// @Param session Transaction session.
+// @Param sale Sale.
+func (d *BillDao) validateNotReturned(session *xorm.Session, sale *Sale) error {
+	returned, err := NewCreditNoteDao(d.GetSchema()).ReturnedAmounts(session, sale.BillId)
+	if err != nil {
+		return err
+	}
+	if returned[sale.Id] > 0 {
+		return &ConflictError{Message: fmt.Sprintf("Sale %d has returns and can not be modified.", sale.Id)}
+	}
+
+	return nil
+}
+
+// @Description Apply the promotions running when the bill was issued over its
+// lines again and resolve their discounts. The returned lines keep their
+// promotions.
+// @Param session Transaction session.
+// @Param bill Bill.
+func (d *BillDao) reapplyPromotions(session *xorm.Session, bill *Bill) error {
+	sales := make([]*Sale, 0)
+	err := session.NoCache().Where("bill_id = ?", bill.Id).Asc("id").Find(&sales)
+	if err != nil {
+		return err
+	}
+	returned, err := NewCreditNoteDao(d.GetSchema()).ReturnedAmounts(session, bill.Id)
+	if err != nil {
+		return err
+	}
+	open := make([]*Sale, 0)
+	for _, sale := range sales {
+		if returned[sale.Id] == 0 {
+			open = append(open, sale)
+		}
+	}
+
+	err = applyPromotions(session, d.GetSchema(), bill.HeadquarterId, open, bill.Created)
+	if err != nil {
+		return err
+	}
+
+	for _, sale := range open {
+		err = sale.applyDiscount()
+		if err != nil {
+			return err
+		}
+		_, err = session.ID(sale.Id).Cols("discount", "discount_type", "promotion_id", "promotion_discount").Update(sale)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @Description Recompute and store the bill subtotal, taxes and total from
+// its sales.
+// @Param session Transaction session.
+// @Param bill Bill.
+func (d *BillDao) updateTotal(session *xorm.Session, bill *Bill) error {
+	saleDao := NewSaleDao(d.GetSchema())
+	sales, err := saleDao.FindByBillInSession(session, bill.Id)
+	if err != nil {
+		return err
+	}
+
+	// Calculate totals.
+	bSales := make([]*Sale, 0)
+	for _, sale := range sales {
+		bSales = append(bSales, &sale.Sale)
+	}
+	err = bill.applyTotals(bSales)
+	if err != nil {
+		return err
+	}
+
+	_, err = session.ID(bill.Id).Cols("discount", "coupon_discount", "subtotal", "tax", "total").Update(bill)
+
+	return err
+}
+
+// @Description Resolve the coupon and bill discounts over its lines and
+// calculate the bill subtotal, taxes and total. The coupon applies first with
+// the terms snapshot on the bill and it does not count for the discounts
+// authorization.
+// @Param sales Bill sales.
+func (b *Bill) applyTotals(sales []*Sale) error {
+	lines, list := 0.0, 0.0
+	for _, sale := range sales {
+		lines += sale.Total()
+		list += sale.listTotal()
+	}
+
+	// Resolve the coupon discount, fixed coupons can not exceed the lines.
+	b.CouponDiscount = 0
+	if b.CouponId > 0 {
+		discountType := b.CouponType
+		if len(discountType) == 0 {
+			discountType = DiscountFixed
+		}
+		var err error
+		b.CouponDiscount, err = discountAmount(discountType, b.CouponRate, math.Min(b.CouponAmount, lines), lines)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	b.Discount, err = discountAmount(b.DiscountType, b.DiscountRate, b.Discount, lines-b.CouponDiscount)
+	if err != nil {
+		return err
+	}
+	b.Subtotal, b.Tax, b.Total = BillAmounts(sales, b.totalDiscount())
+
+	// Validate the discounts authorization.
+	if list-b.Total-b.CouponDiscount > SellerMaxDiscountRate*list/100+cent && len(b.DiscountAuthorizedBy) == 0 {
+		return &ForbiddenError{Message: fmt.Sprintf("Discounts over %.2f%% of the bill require an admin authorization.", SellerMaxDiscountRate)}
+	}
+
+	return nil
+}
+
+// @Description Calculate the bill subtotal, taxes and total. The bill
+// discount is distributed proportionally between the subtotal and the taxes.
+// @Param sales Bill sales.
+// @Param discount Bill discount.
+func BillAmounts(sales []*Sale, discount float64) (subtotal, tax, total float64) {
+	for _, sale := range sales {
+		subtotal += sale.Subtotal()
+		tax += sale.Tax()
+	}
+
+	// Apply discount.
+	if gross := subtotal + tax; gross > 0 {
+		factor := (gross - discount) / gross
+		subtotal *= factor
+		tax *= factor
+	}
+
+	return subtotal, tax, subtotal + tax
+}
+
+// @Description Copy the current product price and cost to the sale.
+// @Param session Transaction session.
+// @Param sale Sale.
+func snapshotProduct(session *xorm.Session, sale *Sale) error {
+	product := new(Product)
+	found, err := session.NoCache().ID(sale.ProductId).Get(product)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", sale.ProductId)}
+	}
+
+	sale.UnitPrice = product.Price
+	sale.UnitCost = product.Cost
+	sale.TaxIncluded = product.TaxIncluded
+	sale.TaxRate = 0
+
+	// Snapshot the product tax rate.
+	if product.TaxRateId > 0 {
+		taxRate := new(TaxRate)
+		found, err = session.NoCache().ID(product.TaxRateId).Get(taxRate)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Tax rate %d does not exist.", product.TaxRateId)}
+		}
+		sale.TaxRate = taxRate.Rate
+	}
+
+	return nil
+}
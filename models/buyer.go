@@ -0,0 +1,182 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"strings"
+	"time"
+)
+
+var (
+	BuyerTableName = "buyer"
+)
+
+// @Description End customer buying in the headquarters.
+type Buyer struct {
+	Id         uint64    `xorm:"pk autoincr" json:"id"`
+	Name       string    `xorm:"not null" json:"name"`
+	DocumentId string    `xorm:"null unique" json:"document_id"`
+	Email      string    `xorm:"index" json:"email"`
+	Phone      string    `json:"phone"`
+	Address    string    `json:"address"`
+	Created    time.Time `xorm:"created" json:"created"`
+	Updated    time.Time `xorm:"updated" json:"updated"`
+}
+
+func (b *Buyer) TableName() string {
+	return BuyerTableName
+}
+
+// @Description Buyers created without document id before it was nullable
+// store NULL so they do not collide in the unique index.
+// @Param session Migration session.
+// @Param schema Customer schema.
+func migrateBuyerDocuments(session *xorm.Session, schema string) error {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BuyerTableName)
+	sql.WriteString(" SET document_id = NULL WHERE document_id = ''")
+
+	// Execute sentence.
+	_, err := session.Exec(sql.String())
+
+	return err
+}
+
+type BuyerDao struct {
+	Dao
+}
+
+func NewBuyerDao(schema string) *BuyerDao {
+	d := new(BuyerDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Create the buyer, the buyers without document id store NULL
+// so several of them fit the unique index.
+// @Param buyer Buyer.
+func (d *BuyerDao) Create(buyer *Buyer) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		buyer.DocumentId = strings.TrimSpace(buyer.DocumentId)
+		if len(buyer.DocumentId) == 0 {
+			_, err := session.Omit("document_id").Insert(buyer)
+			return err
+		}
+
+		err := d.validateDocument(session, buyer)
+		if err != nil {
+			return err
+		}
+
+		_, err = session.Insert(buyer)
+
+		return err
+	})
+}
+
+// @Description Update the buyer given fields.
+// @Param buyer Buyer.
+func (d *BuyerDao) Update(buyer *Buyer) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		err := d.validateExists(session, buyer.Id)
+		if err != nil {
+			return err
+		}
+
+		buyer.DocumentId = strings.TrimSpace(buyer.DocumentId)
+		if len(buyer.DocumentId) > 0 {
+			err = d.validateDocument(session, buyer)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = session.ID(buyer.Id).Update(buyer)
+
+		return err
+	})
+}
+
+// @Description Delete the buyer when no bill or loyalty entry references it.
+// @Param buyerId Buyer Id.
+func (d *BuyerDao) Delete(buyerId uint64) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		err := d.validateExists(session, buyerId)
+		if err != nil {
+			return err
+		}
+
+		bills, err := session.Where("buyer_id = ?", buyerId).Count(new(Bill))
+		if err != nil {
+			return err
+		}
+		if bills > 0 {
+			return &ConflictError{Message: fmt.Sprintf("Buyer %d has %d bills and can not be deleted.", buyerId, bills)}
+		}
+		entries, err := session.Where("buyer_id = ?", buyerId).Count(new(LoyaltyEntry))
+		if err != nil {
+			return err
+		}
+		if entries > 0 {
+			return &ConflictError{Message: fmt.Sprintf("Buyer %d has loyalty points and can not be deleted.", buyerId)}
+		}
+
+		_, err = session.ID(buyerId).Delete(new(Buyer))
+
+		return err
+	})
+}
+
+// @Description Search buyers by name, document id, email or phone.
+// @Param query Search text, empty to get every buyer.
+func (d *BuyerDao) Search(query string) ([]*Buyer, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Asc("name")
+	if len(query) > 0 {
+		like := "%" + query + "%"
+		session = session.Where("name ILIKE ? OR document_id ILIKE ? OR email ILIKE ? OR phone ILIKE ?", like, like, like, like)
+	}
+
+	buyers := make([]*Buyer, 0)
+	err := session.Find(&buyers)
+
+	return buyers, err
+}
+
+// @Description Validate the buyer exists.
+// @Param session Session.
+// @Param buyerId Buyer Id.
+func (d *BuyerDao) validateExists(session *xorm.Session, buyerId uint64) error {
+	found, err := session.NoCache().ID(buyerId).Exist(new(Buyer))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Buyer %d does not exist.", buyerId)}
+	}
+	return nil
+}
+
+// @Description Validate no other buyer has the document id.
+// @Param session Session.
+// @Param buyer Buyer.
+func (d *BuyerDao) validateDocument(session *xorm.Session, buyer *Buyer) error {
+	found, err := session.NoCache().Where("document_id = ? AND id <> ?", buyer.DocumentId, buyer.Id).Exist(new(Buyer))
+	if err != nil {
+		return err
+	}
+	if found {
+		return &ConflictError{Message: fmt.Sprintf("A buyer with document id %s already exists.", buyer.DocumentId)}
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"github.com/go-xorm/xorm"
 	"time"
 )
 
@@ -8,13 +10,19 @@ var (
 	CateringTableName = "catering"
 )
 
+// @Description Products received from a provider in a headquarter. The unit
+// cost of the latest catering of a product is the product cost.
 type Catering struct {
-	Id         uint64    `xorm:"pk autoincr" json:"id"`
-	ProductId  uint64    `xorm:"index" json:"product_id"`
-	ProviderId uint64    `xorm:"index" json:"provider_id"`
-	Amount     uint64    `xorm:"not null" json:"amount"`
-	Created    time.Time `xorm:"created" json:"created"`
-	Updated    time.Time `xorm:"updated" json:"updated"`
+	Id              uint64    `xorm:"pk autoincr" json:"id"`
+	ProductId       uint64    `xorm:"index" json:"product_id"`
+	ProviderId      uint64    `xorm:"index" json:"provider_id"`
+	HeadquarterId   uint64    `xorm:"index not null default 0" json:"headquarter_id"`
+	PurchaseOrderId uint64    `xorm:"index not null default 0" json:"purchase_order_id"`
+	Amount          uint64    `xorm:"not null" json:"amount"`
+	UnitCost        float64   `xorm:"not null default 0" json:"unit_cost"`
+	UserId          string    `json:"user_id"`
+	Created         time.Time `xorm:"created" json:"created"`
+	Updated         time.Time `xorm:"updated" json:"updated"`
 }
 
 func (c *Catering) TableName() string {
@@ -53,3 +61,245 @@ func (d *CateringDao) FindByDates(start, end time.Time) ([]*CateringProviderProd
 
 	return caterings, err
 }
+
+// @Description Create the catering increasing the headquarter stock.
+// @Param catering Catering.
+func (d *CateringDao) Create(catering *Catering) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		err := d.validate(session, catering)
+		if err != nil {
+			return err
+		}
+
+		// Only the purchase order receipts belong to a purchase order.
+		catering.PurchaseOrderId = 0
+
+		return d.create(session, catering, catering.UserId)
+	})
+}
+
+// @Description Update the catering applying the stock difference. The stock
+// can not become negative and the purchase order caterings can not be
+// updated.
+// @Param cateringId Catering Id.
+// @Param catering Catering fields to update.
+// @Param userId User updating the catering.
+func (d *CateringDao) Update(cateringId uint64, catering *Catering, userId string) (*Catering, error) {
+	var current *Catering
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		current, err = d.readForUpdate(session, cateringId)
+		if err != nil {
+			return err
+		}
+		previous := *current
+
+		// Merge the fields to update.
+		if catering.ProductId > 0 {
+			current.ProductId = catering.ProductId
+		}
+		if catering.ProviderId > 0 {
+			current.ProviderId = catering.ProviderId
+		}
+		if catering.HeadquarterId > 0 {
+			current.HeadquarterId = catering.HeadquarterId
+		}
+		if catering.Amount > 0 {
+			current.Amount = catering.Amount
+		}
+		if catering.UnitCost > 0 {
+			current.UnitCost = catering.UnitCost
+		}
+		err = d.validate(session, current)
+		if err != nil {
+			return err
+		}
+
+		// Apply the stock difference.
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		if previous.HeadquarterId == current.HeadquarterId && previous.ProductId == current.ProductId {
+			if current.Amount > previous.Amount {
+				err = headquarterProductDao.IncreaseStock(session, current.HeadquarterId, current.ProductId, current.Amount-previous.Amount, StockReasonCatering, current.Id, userId)
+			} else if current.Amount < previous.Amount {
+				err = headquarterProductDao.DecreaseStock(session, current.HeadquarterId, current.ProductId, previous.Amount-current.Amount, StockReasonCatering, current.Id, userId)
+			}
+		} else {
+			err = d.reverse(session, &previous, userId)
+			if err == nil {
+				err = headquarterProductDao.IncreaseStock(session, current.HeadquarterId, current.ProductId, current.Amount, StockReasonCatering, current.Id, userId)
+			}
+		}
+		if stockError, ok := err.(*StockError); ok {
+			return StockErrors{stockError}
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = session.ID(current.Id).Cols("product_id", "provider_id", "headquarter_id", "amount", "unit_cost").Update(current)
+		if err != nil {
+			return err
+		}
+
+		// Update the product costs.
+		err = d.updateCost(session, previous.ProductId)
+		if err != nil {
+			return err
+		}
+		if current.ProductId != previous.ProductId {
+			return d.updateCost(session, current.ProductId)
+		}
+
+		return nil
+	})
+
+	return current, err
+}
+
+// @Description Delete the catering reversing its stock. The stock can not
+// become negative and the purchase order caterings can not be deleted.
+// @Param cateringId Catering Id.
+// @Param userId User deleting the catering.
+func (d *CateringDao) Delete(cateringId uint64, userId string) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		catering, err := d.readForUpdate(session, cateringId)
+		if err != nil {
+			return err
+		}
+
+		err = d.reverse(session, catering, userId)
+		if stockError, ok := err.(*StockError); ok {
+			return StockErrors{stockError}
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = session.ID(catering.Id).Delete(new(Catering))
+		if err != nil {
+			return err
+		}
+
+		return d.updateCost(session, catering.ProductId)
+	})
+}
+
+// @Description Insert the catering increasing the headquarter stock and
+// updating the product cost.
+// @Param session Transaction session.
+// @Param catering Catering.
+// @Param userId User receiving the products.
+func (d *CateringDao) create(session *xorm.Session, catering *Catering, userId string) error {
+	catering.UserId = userId
+	_, err := session.Insert(catering)
+	if err != nil {
+		return err
+	}
+
+	err = NewHeadquarterProductDao(d.GetSchema()).IncreaseStock(session, catering.HeadquarterId, catering.ProductId, catering.Amount, StockReasonCatering, catering.Id, userId)
+	if err != nil {
+		return err
+	}
+
+	return d.updateCost(session, catering.ProductId)
+}
+
+// @Description Validate the catering references and amount. Caterings
+// without unit cost take the product cost.
+// @Param session Transaction session.
+// @Param catering Catering.
+func (d *CateringDao) validate(session *xorm.Session, catering *Catering) error {
+	if catering.Amount == 0 {
+		return &ValidationError{Message: "The catering must have an amount."}
+	}
+
+	// Validate headquarter.
+	if catering.HeadquarterId == 0 {
+		return &ValidationError{Message: "headquarter_id can not be empty."}
+	}
+	found, err := session.NoCache().ID(catering.HeadquarterId).Exist(new(Headquarter))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", catering.HeadquarterId)}
+	}
+
+	// Validate provider.
+	found, err = session.NoCache().ID(catering.ProviderId).Exist(new(Provider))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Provider %d does not exist.", catering.ProviderId)}
+	}
+
+	// Validate product.
+	product := new(Product)
+	found, err = session.NoCache().ID(catering.ProductId).Get(product)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", catering.ProductId)}
+	}
+	if catering.UnitCost == 0 {
+		catering.UnitCost = product.Cost
+	}
+
+	return nil
+}
+
+// @Description Decrease the headquarter stock increased by the catering. The
+// caterings recorded before they had a headquarter did not increase any
+// stock.
+// @Param session Transaction session.
+// @Param catering Catering.
+// @Param userId User reversing the catering.
+func (d *CateringDao) reverse(session *xorm.Session, catering *Catering, userId string) error {
+	if catering.HeadquarterId == 0 {
+		return nil
+	}
+
+	return NewHeadquarterProductDao(d.GetSchema()).DecreaseStock(session, catering.HeadquarterId, catering.ProductId, catering.Amount, StockReasonCatering, catering.Id, userId)
+}
+
+// @Description Set the product cost to the unit cost of its latest catering.
+// The cost is kept when the product does not have caterings with unit cost.
+// @Param session Transaction session.
+// @Param productId Product Id.
+func (d *CateringDao) updateCost(session *xorm.Session, productId uint64) error {
+	catering := new(Catering)
+	found, err := session.NoCache().Where("product_id = ? AND unit_cost > 0", productId).Desc("id").Get(catering)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	product := new(Product)
+	product.Cost = catering.UnitCost
+	_, err = session.ID(productId).Cols("cost").Update(product)
+
+	return err
+}
+
+// @Description Lock the catering row until the transaction ends.
+// @Param session Transaction session.
+// @Param cateringId Catering Id.
+func (d *CateringDao) readForUpdate(session *xorm.Session, cateringId uint64) (*Catering, error) {
+	catering := new(Catering)
+	found, err := session.NoCache().ForUpdate().ID(cateringId).Get(catering)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Catering %d does not exist.", cateringId)}
+	}
+	if catering.PurchaseOrderId > 0 {
+		return nil, &ConflictError{Message: fmt.Sprintf("Catering %d belongs to purchase order %d.", cateringId, catering.PurchaseOrderId)}
+	}
+
+	return catering, nil
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"strings"
+	"time"
+)
+
+var (
+	CouponTableName           = "coupon"
+	CouponRedemptionTableName = "coupon_redemption"
+)
+
+// @Description Coupon code with a bill discount definition. Zero usage caps
+// mean unlimited uses.
+type Coupon struct {
+	Id              uint64    `xorm:"pk autoincr" json:"id"`
+	Code            string    `xorm:"not null unique" json:"code"`
+	DiscountType    string    `xorm:"not null default 'fixed'" json:"discount_type"`
+	DiscountRate    float64   `xorm:"not null default 0" json:"discount_rate"`
+	Discount        float64   `xorm:"not null default 0" json:"discount"`
+	HeadquarterId   uint64    `xorm:"not null default 0" json:"headquarter_id"`
+	Starts          time.Time `xorm:"null" json:"starts"`
+	Ends            time.Time `xorm:"null" json:"ends"`
+	MaxUses         uint64    `xorm:"not null default 0" json:"max_uses"`
+	MaxUsesPerBuyer uint64    `xorm:"not null default 0" json:"max_uses_per_buyer"`
+	Uses            uint64    `xorm:"not null default 0" json:"uses"`
+	Created         time.Time `xorm:"created" json:"created"`
+	Updated         time.Time `xorm:"updated" json:"updated"`
+}
+
+func (c *Coupon) TableName() string {
+	return CouponTableName
+}
+
+// @Description Validate the coupon discount definition, empty fields are not
+// validated so partial updates can be validated too.
+func (c *Coupon) Validate() error {
+	c.Code = strings.ToUpper(strings.TrimSpace(c.Code))
+
+	switch c.DiscountType {
+	case "", DiscountFixed, DiscountPercentage:
+	default:
+		return &ValidationError{Message: fmt.Sprintf("Discount type %s is not valid.", c.DiscountType)}
+	}
+	if c.DiscountRate < 0 || c.DiscountRate > 100 {
+		return &ValidationError{Message: fmt.Sprintf("Discount rate %.2f must be between 0 and 100.", c.DiscountRate)}
+	}
+	if c.Discount < 0 {
+		return &ValidationError{Message: fmt.Sprintf("Discount %.2f can not be negative.", c.Discount)}
+	}
+
+	return nil
+}
+
+// @Description Validate the coupon can be used in the bill headquarter at
+// the given time.
+// @Param headquarterId Headquarter Id.
+// @Param now Time.
+func (c *Coupon) validateFor(headquarterId uint64, now time.Time) error {
+	if c.HeadquarterId > 0 && c.HeadquarterId != headquarterId {
+		return &ConflictError{Message: fmt.Sprintf("Coupon %s is not valid in headquarter %d.", c.Code, headquarterId)}
+	}
+	if !c.Starts.IsZero() && now.Before(c.Starts) {
+		return &ConflictError{Message: fmt.Sprintf("Coupon %s is not valid yet.", c.Code)}
+	}
+	if !c.Ends.IsZero() && now.After(c.Ends) {
+		return &ConflictError{Message: fmt.Sprintf("Coupon %s has expired.", c.Code)}
+	}
+	if c.MaxUses > 0 && c.Uses >= c.MaxUses {
+		return &ConflictError{Message: fmt.Sprintf("Coupon %s has no uses left.", c.Code)}
+	}
+	return nil
+}
+
+// @Description Coupon use by a bill.
+type CouponRedemption struct {
+	Id       uint64    `xorm:"pk autoincr" json:"id"`
+	CouponId uint64    `xorm:"index" json:"coupon_id"`
+	BillId   uint64    `xorm:"index" json:"bill_id"`
+	Buyer    string    `xorm:"index" json:"buyer"`
+	Created  time.Time `xorm:"created" json:"created"`
+	Updated  time.Time `xorm:"updated" json:"updated"`
+}
+
+func (c *CouponRedemption) TableName() string {
+	return CouponRedemptionTableName
+}
+
+// @Description Bills redeemed before the coupon snapshots take the current
+// coupon discount terms.
+// @Param session Migration session.
+// @Param schema Customer schema.
+func migrateBillCoupons(session *xorm.Session, schema string) error {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b SET coupon_type = c.discount_type, coupon_rate = c.discount_rate, coupon_amount = c.discount FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(CouponTableName)
+	sql.WriteString(" c WHERE b.coupon_id = c.id AND b.coupon_type IS NULL")
+
+	// Execute sentence.
+	_, err := session.Exec(sql.String())
+
+	return err
+}
+
+type CouponDao struct {
+	Dao
+}
+
+func NewCouponDao(schema string) *CouponDao {
+	d := new(CouponDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Get the coupon by code.
+// @Param session Session.
+// @Param code Coupon code.
+func (d *CouponDao) readByCode(session *xorm.Session, code string) (*Coupon, error) {
+	coupon := new(Coupon)
+	found, err := session.Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).Get(coupon)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Coupon %s does not exist.", code)}
+	}
+	return coupon, nil
+}
+
+// @Description Redeem the coupon in the bill. The coupon row stays locked
+// until the transaction ends so simultaneous bills can not exceed its uses.
+// @Param session Transaction session.
+// @Param bill Bill with the coupon code.
+// @Param buyer Buyer identifier for the per buyer caps.
+func (d *CouponDao) redeem(session *xorm.Session, bill *Bill, buyer string) (*Coupon, error) {
+	coupon, err := d.readByCode(session.NoCache().ForUpdate(), bill.CouponCode)
+	if err != nil {
+		return nil, err
+	}
+	err = coupon.validateFor(bill.HeadquarterId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the buyer uses.
+	if coupon.MaxUsesPerBuyer > 0 {
+		if len(buyer) == 0 {
+			return nil, &ValidationError{Message: fmt.Sprintf("Coupon %s requires a buyer.", coupon.Code)}
+		}
+		uses, err := session.Where("coupon_id = ? AND buyer = ?", coupon.Id, buyer).Count(new(CouponRedemption))
+		if err != nil {
+			return nil, err
+		}
+		if uint64(uses) >= coupon.MaxUsesPerBuyer {
+			return nil, &ConflictError{Message: fmt.Sprintf("Buyer %s has no uses left of coupon %s.", buyer, coupon.Code)}
+		}
+	}
+
+	// Count the use.
+	coupon.Uses++
+	_, err = session.ID(coupon.Id).Cols("uses").Update(coupon)
+	if err != nil {
+		return nil, err
+	}
+
+	redemption := new(CouponRedemption)
+	redemption.CouponId = coupon.Id
+	redemption.BillId = bill.Id
+	redemption.Buyer = buyer
+	_, err = session.Insert(redemption)
+	if err != nil {
+		return nil, err
+	}
+
+	bill.snapshotCoupon(coupon)
+	_, err = session.ID(bill.Id).Cols("coupon_id", "coupon_code", "coupon_type", "coupon_rate", "coupon_amount").Update(bill)
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// @Description Give back the coupon use of a voided bill.
+// @Param session Transaction session.
+// @Param bill Bill.
+func (d *CouponDao) release(session *xorm.Session, bill *Bill) error {
+	if bill.CouponId == 0 {
+		return nil
+	}
+
+	coupon := new(Coupon)
+	found, err := session.NoCache().ForUpdate().ID(bill.CouponId).Get(coupon)
+	if err != nil || !found {
+		return err
+	}
+
+	if coupon.Uses > 0 {
+		coupon.Uses--
+	}
+	_, err = session.ID(coupon.Id).Cols("uses").Update(coupon)
+	if err != nil {
+		return err
+	}
+
+	_, err = session.Where("coupon_id = ? AND bill_id = ?", coupon.Id, bill.Id).Delete(new(CouponRedemption))
+
+	return err
+}
+
+// @Description Delete the coupon when no bill has redeemed it.
+// @Param couponId Coupon Id.
+func (d *CouponDao) Delete(couponId uint64) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		found, err := session.NoCache().ForUpdate().ID(couponId).Exist(new(Coupon))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Coupon %d does not exist.", couponId)}
+		}
+
+		redemptions, err := session.Where("coupon_id = ?", couponId).Count(new(CouponRedemption))
+		if err != nil {
+			return err
+		}
+		if redemptions > 0 {
+			return &ConflictError{Message: fmt.Sprintf("Coupon %d has %d redemptions and can not be deleted.", couponId, redemptions)}
+		}
+
+		_, err = session.ID(couponId).Delete(new(Coupon))
+
+		return err
+	})
+}
+
+// @Description Find the coupon redemptions.
+// @Param couponId Coupon Id.
+func (d *CouponDao) FindRedemptions(couponId uint64) ([]*CouponRedemption, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	redemptions := make([]*CouponRedemption, 0)
+	err := engine.Where("coupon_id = ?", couponId).Asc("id").Find(&redemptions)
+
+	return redemptions, err
+}
@@ -0,0 +1,297 @@
+package models
+
+import (
+	"app-rest-inventory/util/stringutil"
+	"bytes"
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	CreditNoteTableName     = "credit_note"
+	CreditNoteLineTableName = "credit_note_line"
+)
+
+// @Description Customer return of a bill.
+type CreditNote struct {
+	Id            uint64            `xorm:"pk autoincr" json:"id"`
+	BillId        uint64            `xorm:"index" json:"bill_id"`
+	HeadquarterId uint64            `xorm:"index" json:"headquarter_id"`
+	UserId        string            `xorm:"index" json:"user_id"`
+	ShiftId       uint64            `xorm:"index not null default 0" json:"shift_id"`
+	RefundMethod  string            `xorm:"not null default 'cash'" json:"refund_method"`
+	Reason        string            `json:"reason"`
+	Total         float64           `xorm:"not null default 0" json:"total"`
+	Lines         []*CreditNoteLine `xorm:"-" json:"lines"`
+	Created       time.Time         `xorm:"created" json:"created"`
+	Updated       time.Time         `xorm:"updated" json:"updated"`
+}
+
+func (c *CreditNote) TableName() string {
+	return CreditNoteTableName
+}
+
+// @Description Returned sale line.
+type CreditNoteLine struct {
+	Id           uint64    `xorm:"pk autoincr" json:"id"`
+	CreditNoteId uint64    `xorm:"index" json:"credit_note_id"`
+	SaleId       uint64    `xorm:"index" json:"sale_id"`
+	ProductId    uint64    `xorm:"index" json:"product_id"`
+	Amount       uint64    `xorm:"not null" json:"amount"`
+	Total        float64   `xorm:"not null default 0" json:"total"`
+	Tax          float64   `xorm:"not null default 0" json:"tax"`
+	Cost         float64   `xorm:"not null default 0" json:"cost"`
+	Created      time.Time `xorm:"created" json:"created"`
+	Updated      time.Time `xorm:"updated" json:"updated"`
+}
+
+func (c *CreditNoteLine) TableName() string {
+	return CreditNoteLineTableName
+}
+
+// In order to filter the returned lines by date and headquarter we need to
+// do a join between credit_note_line, credit_note and bill in the xorm way.
+type CreditNoteLineCreditNoteBill struct {
+	CreditNoteLine `xorm:"extends"`
+	CreditNote     `xorm:"extends"`
+	Bill           `xorm:"extends"`
+}
+
+type CreditNoteDao struct {
+	Dao
+}
+
+func NewCreditNoteDao(schema string) *CreditNoteDao {
+	d := new(CreditNoteDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Create the credit note of a bill and restore the returned
+// stock in a single transaction. Cash refunds are recorded in the open shift
+// of the user.
+// @Param creditNote Credit note with its lines.
+func (d *CreditNoteDao) Create(creditNote *CreditNote) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		// Lock the bill.
+		billDao := NewBillDao(d.GetSchema())
+		bill, err := billDao.readIssuedForUpdate(session, creditNote.BillId)
+		if err != nil {
+			return err
+		}
+
+		// Get the bill sales.
+		sales := make([]*Sale, 0)
+		err = session.NoCache().Where("bill_id = ?", bill.Id).Find(&sales)
+		if err != nil {
+			return err
+		}
+		billSales := make(map[uint64]*Sale)
+		for _, sale := range sales {
+			billSales[sale.Id] = sale
+		}
+
+		// Get the already returned amounts.
+		returned, err := d.ReturnedAmounts(session, bill.Id)
+		if err != nil {
+			return err
+		}
+
+		// Validate lines.
+		if len(creditNote.Lines) == 0 {
+			return &ValidationError{Message: "The credit note must have at least one line."}
+		}
+		for _, line := range creditNote.Lines {
+			sale, ok := billSales[line.SaleId]
+			if !ok {
+				return &NotFoundError{Message: fmt.Sprintf("Sale %d does not exist in bill %d.", line.SaleId, bill.Id)}
+			}
+			if line.Amount == 0 || returned[sale.Id]+line.Amount > sale.Amount {
+				return &ValidationError{Message: fmt.Sprintf("Sale %d can not return %d units. Sold %d, Returned %d.", sale.Id, line.Amount, sale.Amount, returned[sale.Id])}
+			}
+			returned[sale.Id] += line.Amount
+		}
+
+		// Cash refunds are paid out from the user open shift.
+		switch creditNote.RefundMethod {
+		case "":
+			creditNote.RefundMethod = PaymentMethodCash
+		case PaymentMethodCash, PaymentMethodCard, PaymentMethodTransfer, PaymentMethodStoreCredit:
+		default:
+			return &ValidationError{Message: fmt.Sprintf("Refund method %s is not supported.", creditNote.RefundMethod)}
+		}
+		creditNote.ShiftId = 0
+		if creditNote.RefundMethod == PaymentMethodCash {
+			shift, err := NewShiftDao(d.GetSchema()).FindOpen(session, bill.HeadquarterId, creditNote.UserId)
+			if err != nil {
+				return err
+			}
+			if shift == nil {
+				return &ConflictError{Message: fmt.Sprintf("User %s does not have an open shift in headquarter %d.", creditNote.UserId, bill.HeadquarterId)}
+			}
+			creditNote.ShiftId = shift.Id
+		}
+
+		// Insert credit note.
+		creditNote.HeadquarterId = bill.HeadquarterId
+		creditNote.Total = 0
+		_, err = session.Insert(creditNote)
+		if err != nil {
+			return err
+		}
+
+		// The bill and coupon discounts are refunded proportionally to the
+		// lines total.
+		var linesTotal float64
+		for _, sale := range sales {
+			linesTotal += sale.Total()
+		}
+		factor := 1.0
+		if linesTotal > 0 {
+			factor = (linesTotal - bill.totalDiscount()) / linesTotal
+		}
+
+		// Insert lines.
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		for _, line := range creditNote.Lines {
+			sale := billSales[line.SaleId]
+
+			// Restore the stock.
+			err = headquarterProductDao.IncreaseStock(session, bill.HeadquarterId, sale.ProductId, line.Amount, StockReasonReturn, creditNote.Id, creditNote.UserId)
+			if err != nil {
+				return err
+			}
+
+			// Refund the proportional part of the sale line net of the bill
+			// discounts.
+			line.CreditNoteId = creditNote.Id
+			line.ProductId = sale.ProductId
+			line.Total = sale.Total() * factor * float64(line.Amount) / float64(sale.Amount)
+			line.Tax = sale.Tax() * factor * float64(line.Amount) / float64(sale.Amount)
+			line.Cost = float64(line.Amount) * sale.UnitCost
+			_, err = session.Insert(line)
+			if err != nil {
+				return err
+			}
+
+			creditNote.Total += line.Total
+		}
+
+		// Update total.
+		_, err = session.ID(creditNote.Id).Cols("total").Update(creditNote)
+		if err != nil {
+			return err
+		}
+
+		// Take back the points earned by the returned lines.
+		return NewLoyaltyDao(d.GetSchema()).reconcile(session, bill, creditNote.Id)
+	})
+}
+
+// @Description Get the returned amount of every sale of a bill.
+// @Param session Transaction session.
+// @Param billId Bill Id.
+func (d *CreditNoteDao) ReturnedAmounts(session *xorm.Session, billId uint64) (map[uint64]uint64, error) {
+	lines := make([]*CreditNoteLine, 0)
+	err := session.NoCache().Table(CreditNoteLineTableName).
+		Join("INNER", CreditNoteTableName, "credit_note.id = credit_note_line.credit_note_id").
+		Where("credit_note.bill_id = ?", billId).Find(&lines)
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[uint64]uint64)
+	for _, line := range lines {
+		returned[line.SaleId] += line.Amount
+	}
+
+	return returned, nil
+}
+
+// @Description Get the credit notes of a bill with their lines.
+// @Param billId Bill Id.
+func (d *CreditNoteDao) FindByBill(billId uint64) ([]*CreditNote, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Get credit notes.
+	creditNotes := make([]*CreditNote, 0)
+	err := engine.Where("bill_id = ?", billId).Asc("id").Find(&creditNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get lines.
+	for _, creditNote := range creditNotes {
+		creditNote.Lines = make([]*CreditNoteLine, 0)
+		err = engine.Where("credit_note_id = ?", creditNote.Id).Asc("id").Find(&creditNote.Lines)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return creditNotes, nil
+}
+
+// @Description Get the returned lines by dates.
+// @Param start Start time.
+// @Param end End time.
+func (d *CreditNoteDao) FindLinesByDates(start, end time.Time) ([]*CreditNoteLineCreditNoteBill, error) {
+	return d.findLines(nil, start, end)
+}
+
+// @Description Get the returned lines by headquarter ID and dates.
+// @Param headquarterID Headquarter ID.
+// @Param start Start time.
+// @Param end End time.
+func (d *CreditNoteDao) FindLinesByHeadquarterIDAndDates(headquarterID uint64, start, end time.Time) ([]*CreditNoteLineCreditNoteBill, error) {
+	return d.findLines(&headquarterID, start, end)
+}
+
+// @Param headquarterID Optional headquarter ID.
+// @Param start Start time.
+// @Param end End time.
+func (d *CreditNoteDao) findLines(headquarterID *uint64, start, end time.Time) ([]*CreditNoteLineCreditNoteBill, error) {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("SELECT * FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(CreditNoteLineTableName)
+	sql.WriteString(" l INNER JOIN ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(CreditNoteTableName)
+	sql.WriteString(" c ON l.credit_note_id = c.id ")
+	if headquarterID != nil {
+		sql.WriteString("AND c.headquarter_id = ")
+		sql.WriteString(fmt.Sprintf("%v", *headquarterID))
+	}
+	sql.WriteString(" INNER JOIN ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b ON c.bill_id = b.id ")
+	sql.WriteString("WHERE c.created >= '")
+	sql.WriteString(start.UTC().Format(stringutil.UTCFormat))
+	sql.WriteString("' AND c.created <= '")
+	sql.WriteString(end.UTC().Format(stringutil.UTCFormat))
+	sql.WriteString("' ORDER BY l.id ASC")
+
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+	lines := make([]*CreditNoteLineCreditNoteBill, 0)
+
+	// Execute sentence.
+	err := engine.Sql(sql.String()).AllCols().Find(&lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	DiscountFixed      = "fixed"
+	DiscountPercentage = "percentage"
+)
+
+var (
+	// Max discount over the bill list total, in percent, a seller can apply
+	// without the authorization of an admin. Every discount requires the
+	// authorization when it is not configured.
+	SellerMaxDiscountRate float64
+)
+
+// Init discounts configuration.
+func init() {
+	val, err := beego.AppConfig.Float("discounts::sellermaxrate")
+	if err != nil {
+		logs.Error(err.Error())
+		val = 0
+	}
+	SellerMaxDiscountRate = val
+}
+
+// @Description Resolve the discount amount over its base. Fixed discounts
+// keep the given amount and percentage discounts apply the rate to the base.
+// @Param discountType Discount type.
+// @Param rate Discount rate in percent.
+// @Param amount Fixed discount amount.
+// @Param base Amount the discount applies to.
+func discountAmount(discountType string, rate, amount, base float64) (float64, error) {
+	switch discountType {
+	case DiscountFixed:
+	case DiscountPercentage:
+		if rate < 0 || rate > 100 {
+			return 0, &ValidationError{Message: fmt.Sprintf("Discount rate %.2f must be between 0 and 100.", rate)}
+		}
+		amount = base * rate / 100
+	default:
+		return 0, &ValidationError{Message: fmt.Sprintf("Discount type %s is not valid.", discountType)}
+	}
+
+	// Validate the discount does not exceed its base.
+	if amount < 0 || amount > base+cent {
+		return 0, &ValidationError{Message: fmt.Sprintf("Discount %.2f exceeds the amount %.2f it applies to.", amount, base)}
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiscountAmount(t *testing.T) {
+	tests := []struct {
+		name         string
+		discountType string
+		rate         float64
+		amount       float64
+		base         float64
+		expected     float64
+		err          bool
+	}{
+		{name: "fixed", discountType: DiscountFixed, amount: 5, base: 20, expected: 5},
+		{name: "fixed whole base", discountType: DiscountFixed, amount: 20, base: 20, expected: 20},
+		{name: "fixed within a cent", discountType: DiscountFixed, amount: 20.004, base: 20, expected: 20.004},
+		{name: "fixed over the base", discountType: DiscountFixed, amount: 20.01, base: 20, err: true},
+		{name: "fixed negative", discountType: DiscountFixed, amount: -1, base: 20, err: true},
+		{name: "percentage", discountType: DiscountPercentage, rate: 15, amount: 99, base: 20, expected: 3},
+		{name: "percentage whole base", discountType: DiscountPercentage, rate: 100, base: 20, expected: 20},
+		{name: "percentage over 100", discountType: DiscountPercentage, rate: 100.5, base: 20, err: true},
+		{name: "percentage negative", discountType: DiscountPercentage, rate: -1, base: 20, err: true},
+		{name: "unknown type", discountType: "free", amount: 1, base: 20, err: true},
+	}
+
+	for _, test := range tests {
+		amount, err := discountAmount(test.discountType, test.rate, test.amount, test.base)
+		if test.err {
+			if _, ok := err.(*ValidationError); !ok {
+				t.Errorf("%s: expected a validation error, got %v.", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if math.Abs(amount-test.expected) > 1e-9 {
+			t.Errorf("%s: discount %.4f, expected %.4f.", test.name, amount, test.expected)
+		}
+	}
+}
+
+func TestApplyTotalsDiscountCap(t *testing.T) {
+	defer func(rate float64) { SellerMaxDiscountRate = rate }(SellerMaxDiscountRate)
+	SellerMaxDiscountRate = 10
+
+	tests := []struct {
+		name         string
+		discountType string
+		rate         float64
+		discount     float64
+		authorizedBy string
+		lineDiscount float64
+		total        float64
+		forbidden    bool
+	}{
+		{name: "fixed at the cap", discountType: DiscountFixed, discount: 10, total: 90},
+		{name: "percentage at the cap", discountType: DiscountPercentage, rate: 10, total: 90},
+		{name: "over the cap within a cent", discountType: DiscountFixed, discount: 10.004, total: 89.996},
+		{name: "over the cap at the cent tolerance", discountType: DiscountFixed, discount: 10 + cent, total: 90 - cent},
+		{name: "over the cap", discountType: DiscountFixed, discount: 10.01, forbidden: true},
+		{name: "line and bill discounts over the cap", discountType: DiscountFixed, discount: 6, lineDiscount: 5, forbidden: true},
+		{name: "authorized over the cap", discountType: DiscountPercentage, rate: 50, authorizedBy: "admin", total: 50},
+	}
+
+	for _, test := range tests {
+		sale := &Sale{Amount: 2, UnitPrice: 50, Discount: test.lineDiscount, DiscountType: DiscountFixed}
+		bill := &Bill{DiscountType: test.discountType, DiscountRate: test.rate, Discount: test.discount, DiscountAuthorizedBy: test.authorizedBy}
+		err := bill.applyTotals([]*Sale{sale})
+		if test.forbidden {
+			if _, ok := err.(*ForbiddenError); !ok {
+				t.Errorf("%s: expected a forbidden error, got %v.", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if math.Abs(bill.Total-test.total) > 1e-9 {
+			t.Errorf("%s: total %.4f, expected %.4f.", test.name, bill.Total, test.total)
+		}
+	}
+}
+
+func TestApplyTotalsTaxes(t *testing.T) {
+	sale := &Sale{Amount: 1, UnitPrice: 100, TaxRate: 19, DiscountType: DiscountFixed}
+	bill := &Bill{DiscountType: DiscountPercentage, DiscountRate: 10, DiscountAuthorizedBy: "admin"}
+	err := bill.applyTotals([]*Sale{sale})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bill discount is distributed between the subtotal and the taxes.
+	if math.Abs(bill.Discount-11.9) > 1e-9 || math.Abs(bill.Subtotal-90) > 1e-9 || math.Abs(bill.Tax-17.1) > 1e-9 || math.Abs(bill.Total-107.1) > 1e-9 {
+		t.Errorf("Unexpected totals discount %.4f subtotal %.4f tax %.4f total %.4f.", bill.Discount, bill.Subtotal, bill.Tax, bill.Total)
+	}
+}
+
+func TestApplyTotalsCoupon(t *testing.T) {
+	defer func(rate float64) { SellerMaxDiscountRate = rate }(SellerMaxDiscountRate)
+	SellerMaxDiscountRate = 10
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		discount float64
+		coupons  float64
+		total    float64
+	}{
+		{name: "percentage coupon out of the cap", coupon: Coupon{Id: 1, DiscountType: DiscountPercentage, DiscountRate: 20}, discount: 10, coupons: 20, total: 70},
+		{name: "fixed coupon", coupon: Coupon{Id: 1, Discount: 15}, coupons: 15, total: 85},
+		{name: "fixed coupon over the lines", coupon: Coupon{Id: 1, DiscountType: DiscountFixed, Discount: 150}, coupons: 100, total: 0},
+	}
+
+	for _, test := range tests {
+		sale := &Sale{Amount: 1, UnitPrice: 100, DiscountType: DiscountFixed}
+		bill := &Bill{DiscountType: DiscountFixed, Discount: test.discount}
+		bill.snapshotCoupon(&test.coupon)
+
+		// Later changes of the coupon do not change the bill.
+		test.coupon.Discount, test.coupon.DiscountRate = 0, 0
+
+		err := bill.applyTotals([]*Sale{sale})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if math.Abs(bill.CouponDiscount-test.coupons) > 1e-9 || math.Abs(bill.Total-test.total) > 1e-9 {
+			t.Errorf("%s: coupon discount %.4f total %.4f, expected %.4f and %.4f.", test.name, bill.CouponDiscount, bill.Total, test.coupons, test.total)
+		}
+	}
+}
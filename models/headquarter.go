@@ -1,6 +1,9 @@
 package models
 
 import (
+	"bytes"
+	"fmt"
+	"github.com/go-xorm/xorm"
 	"time"
 )
 
@@ -9,15 +12,110 @@ var (
 )
 
 type Headquarter struct {
-	Id         uint64    `xorm:"pk autoincr" json:"id"`
-	CustomerId string    `xorm:"index" json:"customer_id"`
-	Name       string    `xorm:"not null unique" json:"name"`
-	Address    string    `json:"address"`
-	Phone      string    `json:"phone"`
-	Created    time.Time `xorm:"created" json:"created"`
-	Updated    time.Time `xorm:"updated" json:"updated"`
+	Id             uint64    `xorm:"pk autoincr" json:"id"`
+	CustomerId     string    `xorm:"index" json:"customer_id"`
+	Name           string    `xorm:"not null unique" json:"name"`
+	Address        string    `json:"address"`
+	Phone          string    `json:"phone"`
+	BillPrefix     string    `json:"bill_prefix"`
+	LastBillNumber uint64    `xorm:"not null default 0" json:"last_bill_number"`
+	Created        time.Time `xorm:"created" json:"created"`
+	Updated        time.Time `xorm:"updated" json:"updated"`
 }
 
 func (h *Headquarter) TableName() string {
 	return HeadquarterTableName
 }
+
+// @Description Assign the next bill number of the headquarter. The headquarter
+// row stays locked until the transaction ends so concurrent bills wait and the
+// numbers have no gaps, a rollback also releases the number.
+// @Param session Transaction session.
+// @Param bill Bill.
+func nextBillNumber(session *xorm.Session, bill *Bill) error {
+	headquarter := new(Headquarter)
+	found, err := session.NoCache().ForUpdate().ID(bill.HeadquarterId).Get(headquarter)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", bill.HeadquarterId)}
+	}
+
+	// Increase the sequence.
+	headquarter.LastBillNumber++
+	_, err = session.ID(headquarter.Id).Cols("last_bill_number").Update(headquarter)
+	if err != nil {
+		return err
+	}
+
+	bill.Prefix = headquarter.BillPrefix
+	bill.Number = headquarter.LastBillNumber
+
+	return nil
+}
+
+// @Description Number the bills issued before the sequential numbers by
+// headquarter and creation order, then move the sequences after them. Held
+// and expired bills were never issued and keep no number.
+// @Param session Migration session.
+// @Param schema Customer schema.
+func migrateBillNumbers(session *xorm.Session, schema string) error {
+	// Validate there are bills to number.
+	count, err := session.Where("number IS NULL").In("status", BillStatusIssued, BillStatusVoided).Count(new(Bill))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b SET number = n.number FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY headquarter_id ORDER BY id) + ")
+	sql.WriteString("(SELECT COALESCE(MAX(m.number), 0) FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" m WHERE m.headquarter_id = o.headquarter_id) AS number FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" o WHERE o.number IS NULL AND o.status IN ('")
+	sql.WriteString(BillStatusIssued)
+	sql.WriteString("', '")
+	sql.WriteString(BillStatusVoided)
+	sql.WriteString("')) n WHERE b.id = n.id")
+
+	// Execute sentence.
+	_, err = session.Exec(sql.String())
+	if err != nil {
+		return err
+	}
+
+	// Build sentence.
+	sql.Reset()
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(HeadquarterTableName)
+	sql.WriteString(" h SET last_bill_number = (SELECT COALESCE(MAX(b.number), 0) FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b WHERE b.headquarter_id = h.id)")
+
+	// Execute sentence.
+	_, err = session.Exec(sql.String())
+
+	return err
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"bytes"
 	"fmt"
+	"github.com/go-xorm/xorm"
 	"time"
 )
 
@@ -19,6 +20,10 @@ type HeadquarterProduct struct {
 	HeadquarterId uint64    `xorm:"index" json:"headquarter_id"`
 	ProductId     uint64    `xorm:"index" json:"product_id"`
 	Amount        uint64    `xorm:"not null" json:"amount"`
+	Reserved      uint64    `xorm:"not null default 0" json:"reserved"`
+	Frozen        bool      `xorm:"not null default false" json:"frozen"`
+	Minimum       uint64    `xorm:"not null default 0" json:"minimum"`
+	Maximum       uint64    `xorm:"not null default 0" json:"maximum"`
 	Created       time.Time `xorm:"created" json:"created"`
 	Updated       time.Time `xorm:"updated" json:"updated"`
 }
@@ -27,6 +32,32 @@ func (h *HeadquarterProduct) TableName() string {
 	return HeadquarterProductTableName
 }
 
+// @Description Existences not reserved by held bills.
+func (h *HeadquarterProduct) Available() uint64 {
+	if h.Reserved > h.Amount {
+		return 0
+	}
+	return h.Amount - h.Reserved
+}
+
+// @Description Validate the stock can change, the stock of the products
+// being counted can not change until the count is approved or cancelled.
+// @Param amount Amount to change.
+func (h *HeadquarterProduct) validateNotFrozen(amount uint64) error {
+	if h.Frozen {
+		return &StockError{ProductId: h.ProductId, Requested: amount, Message: fmt.Sprintf("Product %d is being counted.", h.ProductId)}
+	}
+	return nil
+}
+
+// @Description Validate the reorder levels, 0 means no level.
+func (h *HeadquarterProduct) ValidateLevels() error {
+	if h.Maximum > 0 && h.Maximum < h.Minimum {
+		return &ValidationError{Message: fmt.Sprintf("The maximum %d can not be lower than the minimum %d.", h.Maximum, h.Minimum)}
+	}
+	return nil
+}
+
 // In order to access the information of the headquarter's products we need to
 // do a join between headquarter_product and product in the xorm way.
 type HeadquarterProductProduct struct {
@@ -34,6 +65,26 @@ type HeadquarterProductProduct struct {
 	Product            `xorm:"extends"`
 }
 
+// @Description Not enough stock to fulfill a sale line.
+type StockError struct {
+	Line      int    `json:"line"`
+	ProductId uint64 `json:"product_id"`
+	Requested uint64 `json:"requested"`
+	Available uint64 `json:"available"`
+	Message   string `json:"message"`
+}
+
+func (e *StockError) Error() string {
+	return e.Message
+}
+
+// @Description Sale lines that can not be fulfilled.
+type StockErrors []*StockError
+
+func (e StockErrors) Error() string {
+	return fmt.Sprintf("%d sale lines do not have enough stock.", len(e))
+}
+
 type HeadquarterProductDao struct {
 	Dao
 }
@@ -303,13 +354,308 @@ func (d *HeadquarterProductDao) Read(headquarterId, productId uint64) (*Headquar
 	return headquarterProductProducts[0], nil
 }
 
-func (d *HeadquarterProductDao) Update(headquarterId, productId uint64, product *HeadquarterProduct) error {
+// @Description Add the product to the headquarter recording its initial
+// stock.
+// @Param headquarterProduct Headquarter product.
+// @Param userId User adding the product.
+func (d *HeadquarterProductDao) Create(headquarterProduct *HeadquarterProduct, userId string) error {
+	err := headquarterProduct.ValidateLevels()
+	if err != nil {
+		return err
+	}
+
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		_, err := session.Insert(headquarterProduct)
+		if err != nil {
+			return err
+		}
+
+		return NewStockMovementDao(d.GetSchema()).record(session, headquarterProduct, int64(headquarterProduct.Amount), StockReasonAdjustment, 0, userId)
+	})
+}
+
+// @Description Set the headquarter product stock. The difference is posted
+// as a count correction adjustment, so it waits for an admin when its value
+// exceeds the approval threshold. The product is read back with the current
+// stock.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param product Headquarter product with the new amount, 0 to keep it.
+// @Param userId User updating the product.
+func (d *HeadquarterProductDao) Update(headquarterId, productId uint64, product *HeadquarterProduct, userId string) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		current, err := d.ReadForUpdate(session, headquarterId, productId)
+		if err != nil {
+			return err
+		}
+
+		// Adjust the stock.
+		if product.Amount > 0 && product.Amount != current.Amount {
+			adjustment := new(StockAdjustment)
+			adjustment.HeadquarterId = headquarterId
+			adjustment.ProductId = productId
+			adjustment.Delta = int64(product.Amount) - int64(current.Amount)
+			adjustment.Reason = AdjustmentReasonCountCorrection
+			adjustment.Notes = "Headquarter product update."
+			adjustment.UserId = userId
+			err = NewStockAdjustmentDao(d.GetSchema()).create(session, adjustment, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = session.NoCache().ID(current.Id).Get(product)
+
+		return err
+	})
+}
+
+// @Description Set the headquarter product reorder levels, 0 removes a
+// level.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param minimum Minimum level, the stock is low at or below it.
+// @Param maximum Maximum level, the replenishment target.
+func (d *HeadquarterProductDao) UpdateLevels(headquarterId, productId, minimum, maximum uint64) (*HeadquarterProduct, error) {
+	var headquarterProduct *HeadquarterProduct
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		headquarterProduct, err = d.ReadForUpdate(session, headquarterId, productId)
+		if err != nil {
+			return &NotFoundError{Message: err.Error()}
+		}
+
+		headquarterProduct.Minimum = minimum
+		headquarterProduct.Maximum = maximum
+		err = headquarterProduct.ValidateLevels()
+		if err != nil {
+			return err
+		}
+
+		_, err = session.ID(headquarterProduct.Id).Cols("minimum", "maximum").Update(headquarterProduct)
+
+		return err
+	})
+
+	return headquarterProduct, err
+}
+
+// @Description Get the headquarter products at or below their minimum level.
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+func (d *HeadquarterProductDao) FindLowStock(headquarterId uint64) ([]*HeadquarterProductProduct, error) {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("SELECT * FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(HeadquarterProductTableName)
+	sql.WriteString(" hp INNER JOIN ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(ProductTableName)
+	sql.WriteString(" p ON hp.product_id = p.id WHERE hp.minimum > 0 AND hp.amount <= hp.minimum")
+	if headquarterId > 0 {
+		sql.WriteString(" AND hp.headquarter_id = ")
+		sql.WriteString(fmt.Sprintf("%v", headquarterId))
+	}
+	sql.WriteString(" ORDER BY hp.headquarter_id, hp.product_id")
+
 	// Get engine.
 	engine := GetEngine(d.GetSchema())
-	_, err := engine.Update(product, &HeadquarterProduct{HeadquarterId: headquarterId, ProductId: productId})
+	headquarterProductProducts := make([]*HeadquarterProductProduct, 0)
+
+	// Execute the sentence.
+	err := engine.Sql(sql.String()).Find(&headquarterProductProducts)
+
+	return headquarterProductProducts, err
+}
+
+// @Description Lock the headquarter product row until the transaction ends.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+func (d *HeadquarterProductDao) ReadForUpdate(session *xorm.Session, headquarterId, productId uint64) (*HeadquarterProduct, error) {
+	headquarterProduct := new(HeadquarterProduct)
+	found, err := session.NoCache().ForUpdate().
+		Where("headquarter_id = ? AND product_id = ?", headquarterId, productId).
+		Get(headquarterProduct)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Product %d does not exist in headquarter %d.", productId, headquarterId)
+	}
+
+	return headquarterProduct, nil
+}
+
+// @Description Lock the headquarter product row until the transaction ends,
+// the row is inserted without stock when the headquarter does not have the
+// product.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+func (d *HeadquarterProductDao) readOrCreateForUpdate(session *xorm.Session, headquarterId, productId uint64) (*HeadquarterProduct, error) {
+	headquarterProduct := new(HeadquarterProduct)
+	found, err := session.NoCache().ForUpdate().
+		Where("headquarter_id = ? AND product_id = ?", headquarterId, productId).
+		Get(headquarterProduct)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return headquarterProduct, nil
+	}
+
+	// Validate the product.
+	found, err = session.NoCache().ID(productId).Exist(new(Product))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", productId)}
+	}
+
+	headquarterProduct.HeadquarterId = headquarterId
+	headquarterProduct.ProductId = productId
+	_, err = session.Insert(headquarterProduct)
+
+	return headquarterProduct, err
+}
+
+// @Description Decrease the headquarter product stock inside a transaction.
+// Returns a *StockError when there are not enough existences, the reserved
+// existences are not available, or when the product is being counted.
+// Falling to the minimum level raises an alert.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param amount Amount to decrease.
+// @Param reason Stock movement reason.
+// @Param referenceId Id of the document changing the stock.
+// @Param userId User changing the stock.
+func (d *HeadquarterProductDao) DecreaseStock(session *xorm.Session, headquarterId, productId, amount uint64, reason string, referenceId uint64, userId string) error {
+	headquarterProduct, err := d.ReadForUpdate(session, headquarterId, productId)
+	if err != nil {
+		return &StockError{ProductId: productId, Requested: amount, Message: err.Error()}
+	}
+
+	// Validate the inventory count.
+	err = headquarterProduct.validateNotFrozen(amount)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Validate stock.
+	if headquarterProduct.Available() < amount {
+		return &StockError{
+			ProductId: productId,
+			Requested: amount,
+			Available: headquarterProduct.Available(),
+			Message:   fmt.Sprintf("Product %d does not have enough stock.", productId),
+		}
+	}
+
+	previous := headquarterProduct.Amount
+	headquarterProduct.Amount -= amount
+	_, err = session.ID(headquarterProduct.Id).Cols("amount").Update(headquarterProduct)
+	if err != nil {
+		return err
+	}
+
+	err = NewStockMovementDao(d.GetSchema()).record(session, headquarterProduct, -int64(amount), reason, referenceId, userId)
+	if err != nil {
+		return err
+	}
+
+	return NewStockAlertDao(d.GetSchema()).check(session, headquarterProduct, previous, reason, referenceId)
+}
+
+// @Description Increase the headquarter product stock inside a transaction.
+// The product is added to the headquarter when it does not have it. Returns
+// a *StockError when the product is being counted.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param amount Amount to increase.
+// @Param reason Stock movement reason.
+// @Param referenceId Id of the document changing the stock.
+// @Param userId User changing the stock.
+func (d *HeadquarterProductDao) IncreaseStock(session *xorm.Session, headquarterId, productId, amount uint64, reason string, referenceId uint64, userId string) error {
+	headquarterProduct, err := d.readOrCreateForUpdate(session, headquarterId, productId)
+	if err != nil {
+		return err
+	}
+
+	// Validate the inventory count.
+	err = headquarterProduct.validateNotFrozen(amount)
+	if err != nil {
+		return err
+	}
+
+	headquarterProduct.Amount += amount
+	_, err = session.ID(headquarterProduct.Id).Cols("amount").Update(headquarterProduct)
+	if err != nil {
+		return err
+	}
+
+	return NewStockMovementDao(d.GetSchema()).record(session, headquarterProduct, int64(amount), reason, referenceId, userId)
+}
+
+// @Description Reserve headquarter product existences inside a transaction.
+// Returns a *StockError when there are not enough available existences or
+// the product is being counted.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param amount Amount to reserve.
+func (d *HeadquarterProductDao) ReserveStock(session *xorm.Session, headquarterId, productId, amount uint64) error {
+	headquarterProduct, err := d.ReadForUpdate(session, headquarterId, productId)
+	if err != nil {
+		return &StockError{ProductId: productId, Requested: amount, Message: err.Error()}
+	}
+
+	// Validate the inventory count.
+	err = headquarterProduct.validateNotFrozen(amount)
+	if err != nil {
+		return err
+	}
+
+	// Validate stock.
+	if headquarterProduct.Available() < amount {
+		return &StockError{
+			ProductId: productId,
+			Requested: amount,
+			Available: headquarterProduct.Available(),
+			Message:   fmt.Sprintf("Product %d does not have enough stock.", productId),
+		}
+	}
+
+	headquarterProduct.Reserved += amount
+	_, err = session.ID(headquarterProduct.Id).Cols("reserved").Update(headquarterProduct)
+
+	return err
+}
+
+// @Description Release reserved headquarter product existences inside a
+// transaction.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param amount Amount to release.
+func (d *HeadquarterProductDao) ReleaseStock(session *xorm.Session, headquarterId, productId, amount uint64) error {
+	headquarterProduct, err := d.ReadForUpdate(session, headquarterId, productId)
+	if err != nil {
+		return err
+	}
+
+	if headquarterProduct.Reserved < amount {
+		amount = headquarterProduct.Reserved
+	}
+	headquarterProduct.Reserved -= amount
+	_, err = session.ID(headquarterProduct.Id).Cols("reserved").Update(headquarterProduct)
+
+	return err
 }
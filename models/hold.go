@@ -0,0 +1,337 @@
+package models
+
+import (
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+// @Description Park the bill and its sales without issuing it. The sales
+// reserve the headquarter stock until the hold expires, if the failure is due
+// to the stock a StockErrors with every failed line is returned.
+// @Param bill Bill.
+// @Param sales Bill sales.
+func (d *BillDao) Hold(bill *Bill, sales []*Sale) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		// Validate the buyer.
+		if bill.BuyerId > 0 {
+			err := NewBuyerDao(d.GetSchema()).validateExists(session, bill.BuyerId)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Validate the coupon without redeeming it.
+		if len(bill.CouponCode) > 0 {
+			coupon, err := NewCouponDao(d.GetSchema()).readByCode(session, bill.CouponCode)
+			if err != nil {
+				return err
+			}
+			err = coupon.validateFor(bill.HeadquarterId, time.Now())
+			if err != nil {
+				return err
+			}
+			bill.snapshotCoupon(coupon)
+		}
+
+		// Insert bill, the number is assigned when it is issued.
+		bill.Status = BillStatusHeld
+		bill.HoldExpires = time.Now().Add(time.Duration(HoldMinutes) * time.Minute)
+		if len(bill.DiscountType) == 0 {
+			bill.DiscountType = DiscountFixed
+		}
+		_, err := session.Omit("number").Insert(bill)
+		if err != nil {
+			return err
+		}
+
+		// Reserve the stock.
+		err = d.reserve(session, bill, sales)
+		if err != nil {
+			return err
+		}
+
+		// Snapshot the product price and cost.
+		for _, sale := range sales {
+			err = snapshotProduct(session, sale)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Apply the running promotions over every line.
+		err = applyPromotions(session, d.GetSchema(), bill.HeadquarterId, sales, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// Insert sales.
+		for _, sale := range sales {
+			// Resolve the line discount.
+			err = sale.applyDiscount()
+			if err != nil {
+				return err
+			}
+
+			// Insert sale.
+			sale.BillId = bill.Id
+			_, err = session.Insert(sale)
+			if err != nil {
+				return err
+			}
+		}
+
+		return d.updateTotal(session, bill)
+	})
+}
+
+// @Description Find the held bills of the headquarter. The expired holds are
+// released first.
+// @Param headquarterId Headquarter Id.
+func (d *BillDao) FindHeld(headquarterId uint64) ([]*Bill, error) {
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		return d.expireHolds(session, headquarterId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	bills := make([]*Bill, 0)
+	err = engine.NoCache().Where("headquarter_id = ? AND status = ?", headquarterId, BillStatusHeld).Asc("created").Find(&bills)
+
+	return bills, err
+}
+
+// @Description Resume the held bill renewing its hold. Expired bills reserve
+// their stock again.
+// @Param billId Bill Id.
+func (d *BillDao) Resume(billId uint64) (*Bill, error) {
+	var bill *Bill
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		bill, err = d.readHeldForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Reserve the stock again.
+		if bill.Status == BillStatusExpired {
+			sales := make([]*Sale, 0)
+			err = session.NoCache().Where("bill_id = ?", billId).Find(&sales)
+			if err != nil {
+				return err
+			}
+			err = d.reserve(session, bill, sales)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Renew the hold.
+		bill.Status = BillStatusHeld
+		bill.HoldExpires = time.Now().Add(time.Duration(HoldMinutes) * time.Minute)
+		_, err = session.ID(bill.Id).Cols("status", "hold_expires").Update(bill)
+
+		return err
+	})
+
+	return bill, err
+}
+
+// @Description Issue the held bill through the same validations of Create.
+// The bill fields left empty keep the held values and the held sales are
+// used when no sales are given.
+// @Param billId Bill Id.
+// @Param bill Bill.
+// @Param sales Bill sales.
+// @Param payments Bill payments.
+func (d *BillDao) Finalize(billId uint64, bill *Bill, sales []*Sale, payments []*Payment) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		held, err := d.readHeldForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Release the hold, the stock is decreased when the bill is issued.
+		heldSales, err := d.release(session, held)
+		if err != nil {
+			return err
+		}
+		_, err = session.Where("bill_id = ?", billId).Delete(new(Sale))
+		if err != nil {
+			return err
+		}
+
+		// Keep the held values.
+		bill.Id = held.Id
+		bill.HeadquarterId = held.HeadquarterId
+		bill.CouponId = 0
+		if len(bill.UserId) == 0 {
+			bill.UserId = held.UserId
+		}
+		if bill.BuyerId == 0 {
+			bill.BuyerId = held.BuyerId
+		}
+		if len(bill.DiscountType) == 0 && bill.Discount == 0 && bill.DiscountRate == 0 {
+			bill.Discount = held.Discount
+			bill.DiscountType = held.DiscountType
+			bill.DiscountRate = held.DiscountRate
+		}
+		if len(bill.DiscountAuthorizedBy) == 0 {
+			bill.DiscountAuthorizedBy = held.DiscountAuthorizedBy
+		}
+		if len(bill.CouponCode) == 0 {
+			bill.CouponCode = held.CouponCode
+		}
+		if len(sales) == 0 {
+			for _, sale := range heldSales {
+				sale.Id = 0
+				sales = append(sales, sale)
+			}
+		}
+
+		return d.issue(session, bill, sales, payments)
+	})
+}
+
+// @Description Cancel the held bill releasing its stock.
+// @Param billId Bill Id.
+func (d *BillDao) CancelHold(billId uint64) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		bill, err := d.readHeldForUpdate(session, billId)
+		if err != nil {
+			return err
+		}
+
+		// Release the stock.
+		_, err = d.release(session, bill)
+		if err != nil {
+			return err
+		}
+
+		// Delete sales and bill.
+		_, err = session.Where("bill_id = ?", billId).Delete(new(Sale))
+		if err != nil {
+			return err
+		}
+		_, err = session.ID(billId).Delete(new(Bill))
+
+		return err
+	})
+}
+
+// @Description Lock the bill row and validate it is held or expired.
+// @Param session Transaction session.
+// @Param billId Bill Id.
+func (d *BillDao) readHeldForUpdate(session *xorm.Session, billId uint64) (*Bill, error) {
+	bill, err := d.ReadForUpdate(session, billId)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != BillStatusHeld && bill.Status != BillStatusExpired {
+		return nil, &ConflictError{Message: fmt.Sprintf("Bill %d is %s.", billId, bill.Status)}
+	}
+
+	return bill, nil
+}
+
+// @Description Reserve the stock of the held bill sales.
+// @Param session Transaction session.
+// @Param bill Bill.
+// @Param sales Bill sales.
+func (d *BillDao) reserve(session *xorm.Session, bill *Bill, sales []*Sale) error {
+	stockErrors := make(StockErrors, 0)
+	headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+	for i, sale := range sales {
+		err := headquarterProductDao.ReserveStock(session, bill.HeadquarterId, sale.ProductId, sale.Amount)
+		if stockError, ok := err.(*StockError); ok {
+			stockError.Line = i
+			stockErrors = append(stockErrors, stockError)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(stockErrors) > 0 {
+		return stockErrors
+	}
+
+	return nil
+}
+
+// @Description Release the stock reserved by the held bill, expired bills
+// have nothing reserved. Returns the bill sales.
+// @Param session Transaction session.
+// @Param bill Bill.
+func (d *BillDao) release(session *xorm.Session, bill *Bill) ([]*Sale, error) {
+	sales := make([]*Sale, 0)
+	err := session.NoCache().Where("bill_id = ?", bill.Id).Asc("id").Find(&sales)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != BillStatusHeld {
+		return sales, nil
+	}
+
+	headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+	for _, sale := range sales {
+		err = headquarterProductDao.ReleaseStock(session, bill.HeadquarterId, sale.ProductId, sale.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sales, nil
+}
+
+// @Description Expire the held bills past their hold releasing their stock.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+func (d *BillDao) expireHolds(session *xorm.Session, headquarterId uint64) error {
+	query := session.NoCache().ForUpdate().Where("status = ? AND hold_expires < ?", BillStatusHeld, time.Now())
+	if headquarterId > 0 {
+		query = query.And("headquarter_id = ?", headquarterId)
+	}
+	bills := make([]*Bill, 0)
+	err := query.Asc("id").Find(&bills)
+	if err != nil {
+		return err
+	}
+
+	for _, bill := range bills {
+		_, err = d.release(session, bill)
+		if err != nil {
+			return err
+		}
+
+		bill.Status = BillStatusExpired
+		_, err = session.ID(bill.Id).Cols("status").Update(bill)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @Description Expire the held bills of every customer with an open engine
+// periodically.
+// @Param interval Time between runs.
+func ExpireHolds(interval time.Duration) {
+	for range time.Tick(interval) {
+		for customerID := range pool.Items() {
+			err := Transaction(customerID, func(session *xorm.Session) error {
+				return NewBillDao(customerID).expireHolds(session, 0)
+			})
+			if err != nil {
+				logs.Error(err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	InventoryCountTableName      = "inventory_count"
+	InventoryCountLineTableName  = "inventory_count_line"
+	InventoryCountEntryTableName = "inventory_count_entry"
+)
+
+// Inventory count status.
+const (
+	InventoryCountStatusOpen        = "open"
+	InventoryCountStatusReconciling = "reconciling"
+	InventoryCountStatusApproved    = "approved"
+	InventoryCountStatusCancelled   = "cancelled"
+)
+
+// @Description Physical count of a headquarter products. The expected
+// quantities are taken when the count is opened and taken again when it is
+// approved, the stock of the counted products can not change while the count
+// is reconciling.
+type InventoryCount struct {
+	Id            uint64                `xorm:"pk autoincr" json:"id"`
+	HeadquarterId uint64                `xorm:"index" json:"headquarter_id"`
+	Status        string                `xorm:"index not null" json:"status"`
+	Notes         string                `json:"notes"`
+	UserId        string                `json:"user_id"`
+	ApprovedBy    string                `json:"approved_by"`
+	Approved      time.Time             `xorm:"null" json:"approved"`
+	Lines         []*InventoryCountLine `xorm:"-" json:"lines"`
+	Created       time.Time             `xorm:"created" json:"created"`
+	Updated       time.Time             `xorm:"updated" json:"updated"`
+}
+
+func (i *InventoryCount) TableName() string {
+	return InventoryCountTableName
+}
+
+// @Description Products with counted units.
+func (i *InventoryCount) countedProductIds() []uint64 {
+	productIds := make([]uint64, 0)
+	for _, line := range i.Lines {
+		if line.Counts > 0 {
+			productIds = append(productIds, line.ProductId)
+		}
+	}
+	return productIds
+}
+
+// @Description Expected and counted quantities of a product. The variance is
+// valued at the product cost taken with the expected quantity.
+type InventoryCountLine struct {
+	Id               uint64    `xorm:"pk autoincr" json:"id"`
+	InventoryCountId uint64    `xorm:"unique(inventory_count_line_product)" json:"inventory_count_id"`
+	ProductId        uint64    `xorm:"unique(inventory_count_line_product)" json:"product_id"`
+	Expected         uint64    `xorm:"not null" json:"expected"`
+	Counted          uint64    `xorm:"not null default 0" json:"counted"`
+	Counts           uint      `xorm:"not null default 0" json:"counts"`
+	Variance         int64     `xorm:"not null default 0" json:"variance"`
+	UnitCost         float64   `xorm:"not null default 0" json:"unit_cost"`
+	Value            float64   `xorm:"not null default 0" json:"value"`
+	AdjustmentId     uint64    `xorm:"not null default 0" json:"adjustment_id"`
+	Created          time.Time `xorm:"created" json:"created"`
+	Updated          time.Time `xorm:"updated" json:"updated"`
+}
+
+func (i *InventoryCountLine) TableName() string {
+	return InventoryCountLineTableName
+}
+
+// @Description Add the units counted by a counter to the line.
+// @Param amount Counted units.
+func (i *InventoryCountLine) addCount(amount uint64) {
+	i.Counted += amount
+	i.Counts++
+	i.value()
+}
+
+// @Description Take the expected quantity from the stock at approval, the
+// stock moved while the count was open is not a variance.
+// @Param stock Headquarter product stock.
+func (i *InventoryCountLine) reconcile(stock uint64) {
+	i.Expected = stock
+	i.value()
+}
+
+// @Description Calculate the line variance and its value at cost.
+func (i *InventoryCountLine) value() {
+	i.Variance = int64(i.Counted) - int64(i.Expected)
+	i.Value = float64(i.Variance) * i.UnitCost
+}
+
+// @Description Units of a product counted by a counter. The entries of every
+// counter are added up in the count line.
+type InventoryCountEntry struct {
+	Id               uint64    `xorm:"pk autoincr" json:"id"`
+	InventoryCountId uint64    `xorm:"index" json:"inventory_count_id"`
+	ProductId        uint64    `xorm:"not null" json:"product_id"`
+	Amount           uint64    `xorm:"not null" json:"amount"`
+	UserId           string    `json:"user_id"`
+	Created          time.Time `xorm:"created" json:"created"`
+}
+
+func (i *InventoryCountEntry) TableName() string {
+	return InventoryCountEntryTableName
+}
+
+type InventoryCountDao struct {
+	Dao
+}
+
+func NewInventoryCountDao(schema string) *InventoryCountDao {
+	d := new(InventoryCountDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Open the inventory count taking the expected quantities from
+// the headquarter stock. A headquarter can only have one count in progress.
+// @Param count Inventory count.
+// @Param productIds Products to count, empty for every headquarter product.
+func (d *InventoryCountDao) Create(count *InventoryCount, productIds []uint64) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		// Validate headquarter.
+		found, err := session.NoCache().ID(count.HeadquarterId).Exist(new(Headquarter))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", count.HeadquarterId)}
+		}
+
+		// Validate there is not another count in progress.
+		found, err = session.NoCache().
+			Where("headquarter_id = ?", count.HeadquarterId).
+			In("status", InventoryCountStatusOpen, InventoryCountStatusReconciling).
+			Exist(new(InventoryCount))
+		if err != nil {
+			return err
+		}
+		if found {
+			return &ConflictError{Message: fmt.Sprintf("Headquarter %d already has an inventory count in progress.", count.HeadquarterId)}
+		}
+
+		// Snapshot the expected quantities.
+		headquarterProducts := make([]*HeadquarterProduct, 0)
+		query := session.NoCache().Where("headquarter_id = ?", count.HeadquarterId)
+		if len(productIds) > 0 {
+			query = query.In("product_id", productIds)
+		}
+		err = query.Asc("product_id").Find(&headquarterProducts)
+		if err != nil {
+			return err
+		}
+		if len(headquarterProducts) == 0 {
+			return &ValidationError{Message: "The inventory count must have at least one product."}
+		}
+		if len(productIds) > 0 {
+			counted := make(map[uint64]bool)
+			for _, headquarterProduct := range headquarterProducts {
+				counted[headquarterProduct.ProductId] = true
+			}
+			for _, productId := range productIds {
+				if !counted[productId] {
+					return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist in headquarter %d.", productId, count.HeadquarterId)}
+				}
+			}
+		}
+
+		// Get the product costs.
+		ids := make([]uint64, 0, len(headquarterProducts))
+		for _, headquarterProduct := range headquarterProducts {
+			ids = append(ids, headquarterProduct.ProductId)
+		}
+		products := make([]*Product, 0)
+		err = session.NoCache().In("id", ids).Find(&products)
+		if err != nil {
+			return err
+		}
+		costs := make(map[uint64]float64)
+		for _, product := range products {
+			costs[product.Id] = product.Cost
+		}
+
+		// Insert count.
+		count.Status = InventoryCountStatusOpen
+		_, err = session.Insert(count)
+		if err != nil {
+			return err
+		}
+
+		// Insert lines.
+		count.Lines = make([]*InventoryCountLine, 0, len(headquarterProducts))
+		for _, headquarterProduct := range headquarterProducts {
+			line := new(InventoryCountLine)
+			line.InventoryCountId = count.Id
+			line.ProductId = headquarterProduct.ProductId
+			line.Expected = headquarterProduct.Amount
+			line.UnitCost = costs[headquarterProduct.ProductId]
+			_, err = session.Insert(line)
+			if err != nil {
+				return err
+			}
+			count.Lines = append(count.Lines, line)
+		}
+
+		return nil
+	})
+}
+
+// @Description Get the inventory count with its lines.
+// @Param countId Inventory count Id.
+func (d *InventoryCountDao) Read(countId uint64) (*InventoryCount, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	count := new(InventoryCount)
+	found, err := session.NoCache().ID(countId).Get(count)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Inventory count %d does not exist.", countId)}
+	}
+
+	count.Lines, err = d.findLines(session, countId)
+
+	return count, err
+}
+
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+// @Param status Inventory count status, empty for every status.
+func (d *InventoryCountDao) FindByHeadquarterAndStatus(headquarterId uint64, status string) ([]*InventoryCount, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Desc("id")
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+	if len(status) > 0 {
+		session = session.And("status = ?", status)
+	}
+
+	counts := make([]*InventoryCount, 0)
+	err := session.Find(&counts)
+
+	return counts, err
+}
+
+// @Description Get the counted lines whose quantity differs from the
+// expected one.
+// @Param countId Inventory count Id.
+func (d *InventoryCountDao) Variances(countId uint64) ([]*InventoryCountLine, error) {
+	count, err := d.Read(countId)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]*InventoryCountLine, 0)
+	for _, line := range count.Lines {
+		if line.Counts > 0 && line.Variance != 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// @Description Add counted units to the count lines. Several counters can
+// count the same product, their entries are added up.
+// @Param countId Inventory count Id.
+// @Param entries Counted units by product.
+// @Param userId Counter.
+func (d *InventoryCountDao) Count(countId uint64, entries []*InventoryCountEntry, userId string) (*InventoryCount, error) {
+	var count *InventoryCount
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		count, err = d.readForUpdate(session, countId, InventoryCountStatusOpen)
+		if err != nil {
+			return err
+		}
+
+		// Index the count lines.
+		lines := make(map[uint64]*InventoryCountLine)
+		for _, line := range count.Lines {
+			lines[line.ProductId] = line
+		}
+
+		for _, entry := range entries {
+			line, ok := lines[entry.ProductId]
+			if !ok {
+				return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist in inventory count %d.", entry.ProductId, countId)}
+			}
+
+			// Insert entry.
+			entry.Id = 0
+			entry.InventoryCountId = countId
+			entry.UserId = userId
+			_, err = session.Insert(entry)
+			if err != nil {
+				return err
+			}
+
+			// Update the line variance.
+			line.addCount(entry.Amount)
+			_, err = session.ID(line.Id).Cols("counted", "counts", "variance", "value").Update(line)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// @Description Close the count to new entries and freeze the stock of the
+// counted products until the count is approved or cancelled.
+// @Param countId Inventory count Id.
+func (d *InventoryCountDao) Reconcile(countId uint64) (*InventoryCount, error) {
+	var count *InventoryCount
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		count, err = d.readForUpdate(session, countId, InventoryCountStatusOpen)
+		if err != nil {
+			return err
+		}
+
+		err = d.freeze(session, count, true)
+		if err != nil {
+			return err
+		}
+
+		count.Status = InventoryCountStatusReconciling
+		_, err = session.ID(count.Id).Cols("status").Update(count)
+
+		return err
+	})
+
+	return count, err
+}
+
+// @Description Approve the reconciling count posting the variances of the
+// counted products against their stock at approval as count correction
+// adjustments. Every adjustment is posted or none is.
+// @Param countId Inventory count Id.
+// @Param userId Admin approving the count.
+func (d *InventoryCountDao) Approve(countId uint64, userId string) (*InventoryCount, error) {
+	var count *InventoryCount
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		count, err = d.readForUpdate(session, countId, InventoryCountStatusReconciling)
+		if err != nil {
+			return err
+		}
+
+		// Unfreeze the stock, the count row lock keeps it until the
+		// adjustments are posted.
+		err = d.freeze(session, count, false)
+		if err != nil {
+			return err
+		}
+
+		// Post the variances.
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		adjustmentDao := NewStockAdjustmentDao(d.GetSchema())
+		for _, line := range count.Lines {
+			if line.Counts == 0 {
+				continue
+			}
+
+			// Take the stock at approval.
+			headquarterProduct, err := headquarterProductDao.ReadForUpdate(session, count.HeadquarterId, line.ProductId)
+			if err != nil {
+				return &NotFoundError{Message: err.Error()}
+			}
+			line.reconcile(headquarterProduct.Amount)
+			_, err = session.ID(line.Id).Cols("expected", "variance", "value").Update(line)
+			if err != nil {
+				return err
+			}
+			if line.Variance == 0 {
+				continue
+			}
+
+			adjustment := new(StockAdjustment)
+			adjustment.HeadquarterId = count.HeadquarterId
+			adjustment.ProductId = line.ProductId
+			adjustment.Delta = line.Variance
+			adjustment.Reason = AdjustmentReasonCountCorrection
+			adjustment.Notes = fmt.Sprintf("Inventory count %d.", count.Id)
+			adjustment.UserId = userId
+			err = adjustmentDao.create(session, adjustment, true)
+			if err != nil {
+				return err
+			}
+
+			line.AdjustmentId = adjustment.Id
+			_, err = session.ID(line.Id).Cols("adjustment_id").Update(line)
+			if err != nil {
+				return err
+			}
+		}
+
+		count.Status = InventoryCountStatusApproved
+		count.ApprovedBy = userId
+		count.Approved = time.Now()
+		_, err = session.ID(count.Id).Cols("status", "approved_by", "approved").Update(count)
+
+		return err
+	})
+
+	return count, err
+}
+
+// @Description Cancel the count in progress without changing the stock.
+// @Param countId Inventory count Id.
+func (d *InventoryCountDao) Cancel(countId uint64) (*InventoryCount, error) {
+	var count *InventoryCount
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		count, err = d.readForUpdate(session, countId, InventoryCountStatusOpen, InventoryCountStatusReconciling)
+		if err != nil {
+			return err
+		}
+
+		if count.Status == InventoryCountStatusReconciling {
+			err = d.freeze(session, count, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		count.Status = InventoryCountStatusCancelled
+		_, err = session.ID(count.Id).Cols("status").Update(count)
+
+		return err
+	})
+
+	return count, err
+}
+
+// @Description Freeze or unfreeze the stock of the counted products.
+// @Param session Transaction session.
+// @Param count Inventory count with its lines.
+// @Param frozen Whether the stock is frozen.
+func (d *InventoryCountDao) freeze(session *xorm.Session, count *InventoryCount, frozen bool) error {
+	productIds := count.countedProductIds()
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	_, err := session.Table(HeadquarterProductTableName).
+		Where("headquarter_id = ?", count.HeadquarterId).
+		In("product_id", productIds).
+		Update(map[string]interface{}{"frozen": frozen})
+
+	return err
+}
+
+// @Description Lock the inventory count row until the transaction ends,
+// validate its status and get its lines.
+// @Param session Transaction session.
+// @Param countId Inventory count Id.
+// @Param status Allowed status.
+func (d *InventoryCountDao) readForUpdate(session *xorm.Session, countId uint64, status ...string) (*InventoryCount, error) {
+	count := new(InventoryCount)
+	found, err := session.NoCache().ForUpdate().ID(countId).Get(count)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Inventory count %d does not exist.", countId)}
+	}
+
+	allowed := false
+	for _, s := range status {
+		allowed = allowed || count.Status == s
+	}
+	if !allowed {
+		return nil, &ConflictError{Message: fmt.Sprintf("Inventory count %d is %s.", countId, count.Status)}
+	}
+
+	count.Lines, err = d.findLines(session, countId)
+
+	return count, err
+}
+
+// @Param session Session.
+// @Param countId Inventory count Id.
+func (d *InventoryCountDao) findLines(session *xorm.Session, countId uint64) ([]*InventoryCountLine, error) {
+	lines := make([]*InventoryCountLine, 0)
+	err := session.NoCache().Where("inventory_count_id = ?", countId).Asc("id").Find(&lines)
+
+	return lines, err
+}
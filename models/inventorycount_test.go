@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestInventoryCountLineVariance(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected uint64
+		counts   []uint64
+		stock    uint64
+		variance int64
+	}{
+		{name: "no variance", expected: 10, counts: []uint64{10}, stock: 10},
+		{name: "counters add up", expected: 10, counts: []uint64{4, 3, 2}, stock: 10, variance: -1},
+		{name: "surplus", expected: 10, counts: []uint64{12}, stock: 10, variance: 2},
+		{name: "sale between create and approve", expected: 10, counts: []uint64{8}, stock: 8},
+		{name: "sale and loss between create and approve", expected: 10, counts: []uint64{7}, stock: 8, variance: -1},
+		{name: "catering between create and approve", expected: 10, counts: []uint64{15}, stock: 15},
+	}
+
+	for _, test := range tests {
+		line := &InventoryCountLine{Expected: test.expected, UnitCost: 2.5}
+		for _, amount := range test.counts {
+			line.addCount(amount)
+		}
+		if line.Counts != uint(len(test.counts)) {
+			t.Errorf("%s: %d counts, expected %d.", test.name, line.Counts, len(test.counts))
+		}
+
+		// The posted variance is taken against the stock at approval.
+		line.reconcile(test.stock)
+		if line.Expected != test.stock || line.Variance != test.variance || math.Abs(line.Value-float64(test.variance)*2.5) > 1e-9 {
+			t.Errorf("%s: expected %d variance %d value %.2f, expected %d and %d.", test.name, line.Expected, line.Variance, line.Value, test.stock, test.variance)
+		}
+	}
+}
+
+func TestInventoryCountCountedProductIds(t *testing.T) {
+	count := &InventoryCount{Lines: []*InventoryCountLine{
+		{ProductId: 1, Counts: 2},
+		{ProductId: 2},
+		{ProductId: 3, Counts: 1},
+	}}
+
+	// Only the counted products are frozen.
+	if productIds := count.countedProductIds(); !reflect.DeepEqual(productIds, []uint64{1, 3}) {
+		t.Errorf("Counted products %v, expected [1 3].", productIds)
+	}
+}
+
+func TestHeadquarterProductValidateNotFrozen(t *testing.T) {
+	if err := (&HeadquarterProduct{ProductId: 1, Amount: 5}).validateNotFrozen(1); err != nil {
+		t.Errorf("Unexpected error %v.", err)
+	}
+
+	err := (&HeadquarterProduct{ProductId: 1, Amount: 5, Frozen: true}).validateNotFrozen(1)
+	if stockError, ok := err.(*StockError); !ok || stockError.ProductId != 1 || stockError.Requested != 1 {
+		t.Errorf("Expected a stock error, got %v.", err)
+	}
+}
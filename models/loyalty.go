@@ -0,0 +1,534 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"github.com/go-xorm/xorm"
+	"math"
+	"time"
+)
+
+var (
+	LoyaltyEntryTableName = "loyalty_entry"
+
+	// Points earned by every 100 of net bill total.
+	LoyaltyEarnRate float64
+	// Value of a point redeemed as payment.
+	LoyaltyPointValue float64
+	// Days before the earned points expire, 0 to never expire them.
+	LoyaltyExpirationDays int
+)
+
+// Loyalty entry types.
+const (
+	LoyaltyEarn    = "earn"
+	LoyaltyRedeem  = "redeem"
+	LoyaltyExpire  = "expire"
+	LoyaltyReverse = "reverse"
+)
+
+// Init loyalty configuration.
+func init() {
+	val, err := beego.AppConfig.Float("loyalty::earnrate")
+	if err != nil {
+		logs.Error(err.Error())
+	}
+	LoyaltyEarnRate = val
+
+	val, err = beego.AppConfig.Float("loyalty::pointvalue")
+	if err != nil {
+		logs.Error(err.Error())
+		val = 1
+	}
+	LoyaltyPointValue = val
+
+	days, err := beego.AppConfig.Int("loyalty::expirationdays")
+	if err != nil {
+		logs.Error(err.Error())
+	}
+	LoyaltyExpirationDays = days
+}
+
+// @Description Buyer points ledger entry. Entries are never updated except
+// the remaining points of the entries adding points, which are spent by the
+// redemptions and expirations in expiration order. The earn entries record the
+// bill net total and the reverse entries the bill returned total their points
+// are computed on.
+type LoyaltyEntry struct {
+	Id           uint64    `xorm:"pk autoincr" json:"id"`
+	BuyerId      uint64    `xorm:"index not null" json:"buyer_id"`
+	BillId       uint64    `xorm:"index not null default 0" json:"bill_id"`
+	CreditNoteId uint64    `xorm:"not null default 0" json:"credit_note_id"`
+	Type         string    `xorm:"not null" json:"type"`
+	Points       int64     `xorm:"not null default 0" json:"points"`
+	Base         float64   `xorm:"not null default 0" json:"base"`
+	Remaining    int64     `xorm:"not null default 0" json:"remaining"`
+	Expires      time.Time `xorm:"null" json:"expires"`
+	Created      time.Time `xorm:"created" json:"created"`
+	Updated      time.Time `xorm:"updated" json:"updated"`
+}
+
+func (l *LoyaltyEntry) TableName() string {
+	return LoyaltyEntryTableName
+}
+
+type LoyaltyDao struct {
+	Dao
+}
+
+func NewLoyaltyDao(schema string) *LoyaltyDao {
+	d := new(LoyaltyDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Get the buyer points balance after expiring the due points.
+// The available points are the balance minus the points owed by reversals of
+// already spent points.
+// @Param buyerId Buyer Id.
+func (d *LoyaltyDao) Balance(buyerId uint64) (balance, available int64, err error) {
+	err = Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		err := d.lockBuyer(session, buyerId)
+		if err != nil {
+			return err
+		}
+
+		err = d.expire(session, buyerId, time.Now())
+		if err != nil {
+			return err
+		}
+
+		balance, available, err = d.balance(session, buyerId)
+		return err
+	})
+
+	return balance, available, err
+}
+
+// @Description Get the buyer points ledger.
+// @Param buyerId Buyer Id.
+func (d *LoyaltyDao) FindByBuyer(buyerId uint64) ([]*LoyaltyEntry, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	entries := make([]*LoyaltyEntry, 0)
+	err := engine.Where("buyer_id = ?", buyerId).Desc("id").Find(&entries)
+
+	return entries, err
+}
+
+// @Description Lock the buyer row until the transaction ends so the buyer
+// points are changed by a single transaction at a time.
+// @Param session Transaction session.
+// @Param buyerId Buyer Id.
+func (d *LoyaltyDao) lockBuyer(session *xorm.Session, buyerId uint64) error {
+	found, err := session.NoCache().ForUpdate().ID(buyerId).Get(new(Buyer))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Buyer %d does not exist.", buyerId)}
+	}
+	return nil
+}
+
+// @Description Get the buyer balance and available points.
+// @Param session Transaction session.
+// @Param buyerId Buyer Id.
+func (d *LoyaltyDao) balance(session *xorm.Session, buyerId uint64) (balance, available int64, err error) {
+	balance, err = session.Where("buyer_id = ?", buyerId).SumInt(new(LoyaltyEntry), "points")
+	if err != nil {
+		return 0, 0, err
+	}
+	remaining, err := session.Where("buyer_id = ? AND remaining > 0", buyerId).SumInt(new(LoyaltyEntry), "remaining")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	available = remaining
+	if balance < available {
+		available = balance
+	}
+	if available < 0 {
+		available = 0
+	}
+
+	return balance, available, nil
+}
+
+// @Description Expire the remaining points of the buyer entries due at the
+// given time.
+// @Param session Transaction session.
+// @Param buyerId Buyer Id.
+// @Param now Time.
+func (d *LoyaltyDao) expire(session *xorm.Session, buyerId uint64, now time.Time) error {
+	entries := make([]*LoyaltyEntry, 0)
+	err := session.NoCache().Where("buyer_id = ? AND remaining > 0 AND expires IS NOT NULL", buyerId).Find(&entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Expires.After(now) {
+			continue
+		}
+
+		expiration := new(LoyaltyEntry)
+		expiration.BuyerId = buyerId
+		expiration.Type = LoyaltyExpire
+		expiration.Points = -entry.Remaining
+		_, err = session.Insert(expiration)
+		if err != nil {
+			return err
+		}
+
+		entry.Remaining = 0
+		_, err = session.ID(entry.Id).Cols("remaining").Update(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @Description Add points to the buyer ledger.
+// @Param session Transaction session.
+// @Param entry Entry adding points.
+func (d *LoyaltyDao) credit(session *xorm.Session, entry *LoyaltyEntry) error {
+	entry.Remaining = entry.Points
+	if LoyaltyExpirationDays > 0 {
+		entry.Expires = time.Now().AddDate(0, 0, LoyaltyExpirationDays)
+	}
+	_, err := session.Insert(entry)
+
+	return err
+}
+
+// @Description Spend remaining points of the buyer entries, the next points
+// to expire are spent first.
+// @Param session Transaction session.
+// @Param buyerId Buyer Id.
+// @Param points Points to spend.
+// @Param billId Only spend the points added by this bill, 0 for every entry.
+func (d *LoyaltyDao) spend(session *xorm.Session, buyerId uint64, points int64, billId uint64) error {
+	session = session.NoCache().Where("buyer_id = ? AND remaining > 0", buyerId)
+	if billId > 0 {
+		session = session.And("bill_id = ?", billId)
+	}
+	entries := make([]*LoyaltyEntry, 0)
+	err := session.OrderBy("expires ASC NULLS LAST, id ASC").Find(&entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if points <= 0 {
+			break
+		}
+		spent := entry.Remaining
+		if spent > points {
+			spent = points
+		}
+		entry.Remaining -= spent
+		points -= spent
+		_, err = session.ID(entry.Id).Cols("remaining").Update(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @Description Earn the points of the bill net total, the total paid with
+// points does not earn points.
+// @Param session Transaction session.
+// @Param bill Bill.
+// @Param payments Bill payments.
+func (d *LoyaltyDao) earn(session *xorm.Session, bill *Bill, payments []*Payment) error {
+	if bill.BuyerId == 0 {
+		return nil
+	}
+
+	net := bill.Total
+	for _, payment := range payments {
+		if payment.Method == PaymentMethodLoyalty {
+			net -= payment.Amount
+		}
+	}
+
+	points := int64(math.Floor(net * LoyaltyEarnRate / 100))
+	if points <= 0 {
+		return nil
+	}
+
+	entry := new(LoyaltyEntry)
+	entry.BuyerId = bill.BuyerId
+	entry.BillId = bill.Id
+	entry.Type = LoyaltyEarn
+	entry.Points = points
+	entry.Base = net
+
+	return d.credit(session, entry)
+}
+
+// @Description Redeem the points of a loyalty payment.
+// @Param session Transaction session.
+// @Param bill Bill.
+// @Param payment Loyalty payment.
+func (d *LoyaltyDao) redeem(session *xorm.Session, bill *Bill, payment *Payment) error {
+	if bill.BuyerId == 0 {
+		return &ValidationError{Message: "Loyalty points payments require a buyer."}
+	}
+	if LoyaltyPointValue <= 0 {
+		return &ConflictError{Message: "Loyalty points can not be redeemed."}
+	}
+
+	err := d.lockBuyer(session, bill.BuyerId)
+	if err != nil {
+		return err
+	}
+	err = d.expire(session, bill.BuyerId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Validate the available points.
+	points := int64(math.Ceil(payment.Amount/LoyaltyPointValue - cent))
+	_, available, err := d.balance(session, bill.BuyerId)
+	if err != nil {
+		return err
+	}
+	if points > available {
+		return &ConflictError{Message: fmt.Sprintf("Buyer %d has %d points available, %d are required.", bill.BuyerId, available, points)}
+	}
+
+	err = d.spend(session, bill.BuyerId, points, 0)
+	if err != nil {
+		return err
+	}
+
+	entry := new(LoyaltyEntry)
+	entry.BuyerId = bill.BuyerId
+	entry.BillId = bill.Id
+	entry.Type = LoyaltyRedeem
+	entry.Points = -points
+	_, err = session.Insert(entry)
+
+	return err
+}
+
+// @Description Reverse every points entry of a voided bill, earned points are
+// taken back and redeemed points are given back.
+// @Param session Transaction session.
+// @Param bill Bill.
+func (d *LoyaltyDao) reverseBill(session *xorm.Session, bill *Bill) error {
+	if bill.BuyerId == 0 {
+		return nil
+	}
+
+	err := d.lockBuyer(session, bill.BuyerId)
+	if err != nil {
+		return err
+	}
+
+	net, err := session.Where("bill_id = ? AND type != ?", bill.Id, LoyaltyExpire).SumInt(new(LoyaltyEntry), "points")
+	if err != nil || net == 0 {
+		return err
+	}
+
+	entry := new(LoyaltyEntry)
+	entry.BuyerId = bill.BuyerId
+	entry.BillId = bill.Id
+	entry.Type = LoyaltyReverse
+	entry.Points = -net
+	if entry.Points > 0 {
+		return d.credit(session, entry)
+	}
+
+	err = d.spend(session, bill.BuyerId, net, bill.Id)
+	if err != nil {
+		return err
+	}
+	_, err = session.Insert(entry)
+
+	return err
+}
+
+// @Description Compute the points earned by a bill net total and the points
+// taken back by its returned total. The returns take back the points of their
+// share of the net total, so the points of a fully returned bill are taken
+// back whatever the order and rounding of its credit notes.
+// @Param net Bill total not paid with points.
+// @Param total Bill total.
+// @Param returned Total of the bill credit notes.
+func loyaltyPoints(net, total, returned float64) (earned, reversed int64) {
+	if net <= 0 || total <= 0 {
+		return 0, 0
+	}
+	earned = int64(math.Floor(net * LoyaltyEarnRate / 100))
+	if returned < cent {
+		return earned, 0
+	}
+	if total-returned < cent {
+		return earned, earned
+	}
+
+	kept := int64(math.Floor(net * (total - returned) / total * LoyaltyEarnRate / 100))
+	if kept < 0 {
+		kept = 0
+	}
+	if kept > earned {
+		kept = earned
+	}
+
+	return earned, earned - kept
+}
+
+// @Description Bring the points of an issued bill in line with its current
+// total and returns. The difference with the points earned and taken back so
+// far is posted as new earn and reverse entries, so the bill changes and
+// returns never drift from the points due.
+// @Param session Transaction session.
+// @Param bill Bill.
+// @Param creditNoteId Credit note causing the change, 0 for bill changes.
+func (d *LoyaltyDao) reconcile(session *xorm.Session, bill *Bill, creditNoteId uint64) error {
+	if bill.BuyerId == 0 {
+		return nil
+	}
+
+	err := d.lockBuyer(session, bill.BuyerId)
+	if err != nil {
+		return err
+	}
+
+	// Get the net and returned totals.
+	paid, err := session.NoCache().Where("bill_id = ? AND method = ?", bill.Id, PaymentMethodLoyalty).Sum(new(Payment), "amount")
+	if err != nil {
+		return err
+	}
+	returned, err := session.NoCache().Where("bill_id = ?", bill.Id).Sum(new(CreditNote), "total")
+	if err != nil {
+		return err
+	}
+	net := bill.Total - paid
+	earned, reversed := loyaltyPoints(net, bill.Total, returned)
+
+	// Get the points earned and taken back so far.
+	entries := make([]*LoyaltyEntry, 0)
+	err = session.NoCache().Where("bill_id = ?", bill.Id).In("type", LoyaltyEarn, LoyaltyReverse).Asc("id").Find(&entries)
+	if err != nil {
+		return err
+	}
+	var currentEarned, currentReversed int64
+	var base float64
+	hasEarn := false
+	for _, entry := range entries {
+		if entry.Type == LoyaltyEarn {
+			currentEarned += entry.Points
+			base = entry.Base
+			hasEarn = true
+		} else {
+			currentReversed -= entry.Points
+		}
+	}
+
+	// Earn the points of the new net total.
+	points := earned - currentEarned
+	if points != 0 || (hasEarn && math.Abs(base-net) > cent) {
+		err = d.post(session, bill, LoyaltyEarn, points, net, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Take back the points of the returned total.
+	points = reversed - currentReversed
+	if points != 0 {
+		return d.post(session, bill, LoyaltyReverse, -points, returned, creditNoteId)
+	}
+
+	return nil
+}
+
+// @Description Post a bill entry, the points added can be spent and the
+// points taken are spent from the bill entries.
+// @Param session Transaction session.
+// @Param bill Bill.
+// @Param entryType Entry type.
+// @Param points Points added or taken.
+// @Param base Total the bill points are computed on.
+// @Param creditNoteId Credit note Id, 0 for none.
+func (d *LoyaltyDao) post(session *xorm.Session, bill *Bill, entryType string, points int64, base float64, creditNoteId uint64) error {
+	entry := new(LoyaltyEntry)
+	entry.BuyerId = bill.BuyerId
+	entry.BillId = bill.Id
+	entry.CreditNoteId = creditNoteId
+	entry.Type = entryType
+	entry.Points = points
+	entry.Base = base
+	if points >= 0 {
+		return d.credit(session, entry)
+	}
+
+	err := d.spend(session, bill.BuyerId, -points, bill.Id)
+	if err != nil {
+		return err
+	}
+	_, err = session.Insert(entry)
+
+	return err
+}
+
+// @Description Fill the totals the existing bill entries were computed on.
+// @Param session Migration session.
+// @Param schema Customer schema.
+func migrateLoyaltyBases(session *xorm.Session, schema string) error {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(LoyaltyEntryTableName)
+	sql.WriteString(" e SET base = b.total - COALESCE((SELECT SUM(p.amount) FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(PaymentTableName)
+	sql.WriteString(" p WHERE p.bill_id = b.id AND p.method = ?), 0) FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b WHERE e.bill_id = b.id AND e.type = ?")
+
+	// Execute sentence.
+	_, err := session.Exec(sql.String(), PaymentMethodLoyalty, LoyaltyEarn)
+	if err != nil {
+		return err
+	}
+
+	// Build sentence.
+	sql.Reset()
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(LoyaltyEntryTableName)
+	sql.WriteString(" e SET base = (SELECT COALESCE(SUM(c.total), 0) FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(CreditNoteTableName)
+	sql.WriteString(" c WHERE c.bill_id = e.bill_id AND c.id <= e.credit_note_id) WHERE e.type = ? AND e.credit_note_id > 0")
+
+	// Execute sentence.
+	_, err = session.Exec(sql.String(), LoyaltyReverse)
+
+	return err
+}
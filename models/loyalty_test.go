@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestLoyaltyPoints(t *testing.T) {
+	defer func(rate float64) { LoyaltyEarnRate = rate }(LoyaltyEarnRate)
+	LoyaltyEarnRate = 1
+
+	tests := []struct {
+		name     string
+		net      float64
+		total    float64
+		returned float64
+		earned   int64
+		reversed int64
+	}{
+		{name: "no returns", net: 1050, total: 1050, earned: 10},
+		{name: "paid with points", net: 950, total: 1050, earned: 9},
+		{name: "nothing to earn", net: 0, total: 1050, returned: 1050},
+		{name: "first third returned", net: 1050, total: 1050, returned: 350, earned: 10, reversed: 3},
+		{name: "second third returned", net: 1050, total: 1050, returned: 700, earned: 10, reversed: 7},
+		{name: "every third returned", net: 1050, total: 1050, returned: 1050, earned: 10, reversed: 10},
+		{name: "returned at the cent tolerance", net: 1050, total: 1050, returned: 1050 - cent/2, earned: 10, reversed: 10},
+		{name: "returned below the cent tolerance", net: 1050, total: 1050, returned: cent / 2, earned: 10},
+		{name: "total lowered after a return", net: 500, total: 500, returned: 100, earned: 5, reversed: 1},
+		{name: "total raised after a return", net: 2000, total: 2000, returned: 100, earned: 20, reversed: 1},
+	}
+
+	for _, test := range tests {
+		earned, reversed := loyaltyPoints(test.net, test.total, test.returned)
+		if earned != test.earned || reversed != test.reversed {
+			t.Errorf("%s: earned %d reversed %d, expected %d and %d.", test.name, earned, reversed, test.earned, test.reversed)
+		}
+	}
+}
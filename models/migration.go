@@ -0,0 +1,68 @@
+package models
+
+import (
+	"bytes"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	MigrationTableName = "migration"
+)
+
+// @Description Data migration applied to the customer schema. Every
+// migration runs once, the row records it was applied.
+type Migration struct {
+	Id      string    `xorm:"pk" json:"id"`
+	Created time.Time `xorm:"created" json:"created"`
+}
+
+func (m *Migration) TableName() string {
+	return MigrationTableName
+}
+
+// @Description Apply the migration in a transaction unless it was already
+// applied. The migration row is claimed first, so an engine created at the
+// same time waits for the transaction and skips the migration.
+// @Param engine Customer engine.
+// @Param schema Customer schema.
+// @Param id Migration Id.
+// @Param fn Migration.
+func runMigration(engine *xorm.Engine, schema, id string, fn func(*xorm.Session, string) error) error {
+	session := engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("INSERT INTO ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(MigrationTableName)
+	sql.WriteString(" (id, created) VALUES (?, ?) ON CONFLICT (id) DO NOTHING")
+
+	// Claim the migration.
+	result, err := session.Exec(sql.String(), id, time.Now())
+	if err != nil {
+		session.Rollback()
+		return err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil || claimed == 0 {
+		session.Rollback()
+		return err
+	}
+
+	err = fn(session, schema)
+	if err != nil {
+		session.Rollback()
+		return err
+	}
+
+	return session.Commit()
+}
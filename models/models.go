@@ -106,7 +106,7 @@ func CreateCustomerSchema(customerID string) error {
 	pool.Set(customerID, engine, time.Duration(ExpirationTime)*time.Minute)
 
 	// Sync the tables.
-	err = engine.Sync2(new(Bill), new(Catering), new(Headquarter), new(HeadquarterProduct), new(Product), new(Provider), new(Sale))
+	err = engine.Sync2(new(Bill), new(Buyer), new(CashMovement), new(Catering), new(Coupon), new(CouponRedemption), new(CreditNote), new(CreditNoteLine), new(Headquarter), new(HeadquarterProduct), new(InventoryCount), new(InventoryCountEntry), new(InventoryCountLine), new(LoyaltyEntry), new(Migration), new(Payment), new(Product), new(Promotion), new(Provider), new(PurchaseOrder), new(PurchaseOrderLine), new(Sale), new(Shift), new(StockAdjustment), new(StockAlert), new(StockMovement), new(TaxRate), new(Transfer), new(TransferLine))
 	if err != nil {
 		logs.Error(err.Error())
 		return err
@@ -147,7 +147,14 @@ func GetEngine(customerID string) *xorm.Engine {
 	engine.SetMaxOpenConns(MaxOpenConns)
 
 	// Sync the tables.
-	err = engine.Sync2(new(Bill), new(Catering), new(Headquarter), new(HeadquarterProduct), new(Product), new(Provider), new(Sale))
+	err = engine.Sync2(new(Bill), new(Buyer), new(CashMovement), new(Catering), new(Coupon), new(CouponRedemption), new(CreditNote), new(CreditNoteLine), new(Headquarter), new(HeadquarterProduct), new(InventoryCount), new(InventoryCountEntry), new(InventoryCountLine), new(LoyaltyEntry), new(Migration), new(Payment), new(Product), new(Promotion), new(Provider), new(PurchaseOrder), new(PurchaseOrderLine), new(Sale), new(Shift), new(StockAdjustment), new(StockAlert), new(StockMovement), new(TaxRate), new(Transfer), new(TransferLine))
+	if err != nil {
+		logs.Error(err.Error())
+		return nil
+	}
+
+	// Migrate the existing data.
+	err = migrate(engine, customerID)
 	if err != nil {
 		logs.Error(err.Error())
 		return nil
@@ -159,6 +166,33 @@ func GetEngine(customerID string) *xorm.Engine {
 	return engine
 }
 
+// @Description Fill the columns added to existing tables.
+// @Param engine Customer engine.
+// @Param customerID Customer ID.
+func migrate(engine *xorm.Engine, customerID string) error {
+	err := runMigration(engine, customerID, "sale_snapshots", migrateSaleSnapshots)
+	if err != nil {
+		return err
+	}
+
+	err = runMigration(engine, customerID, "bill_coupons", migrateBillCoupons)
+	if err != nil {
+		return err
+	}
+
+	err = runMigration(engine, customerID, "buyer_documents", migrateBuyerDocuments)
+	if err != nil {
+		return err
+	}
+
+	err = runMigration(engine, customerID, "bill_numbers", migrateBillNumbers)
+	if err != nil {
+		return err
+	}
+
+	return runMigration(engine, customerID, "loyalty_bases", migrateLoyaltyBases)
+}
+
 // @Param customerID Customer ID
 // @Param model Model.
 func Insert(customerID string, model interface{}) error {
@@ -203,6 +237,67 @@ func Delete(customerID string, modelID interface{}, model interface{}) error {
 	return err
 }
 
+// @Param customerID Customer ID
+// @Param fn Function executed inside the transaction.
+func Transaction(customerID string, fn func(*xorm.Session) error) error {
+	engine := GetEngine(customerID)
+
+	// Create the session.
+	session := engine.NewSession()
+	defer session.Close()
+
+	// Begin the transaction.
+	err := session.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback everything if something fails.
+	err = fn(session)
+	if err != nil {
+		session.Rollback()
+		return err
+	}
+
+	return session.Commit()
+}
+
+// @Description The requested model does not exist.
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// @Description The model state does not allow the operation.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// @Description The request data is not valid for the model.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// @Description The user is not allowed to perform the operation.
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
 // Dao interface.
 type Dao interface {
 	GetSchema() string
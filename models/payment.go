@@ -0,0 +1,236 @@
+package models
+
+import (
+	"app-rest-inventory/util/stringutil"
+	"bytes"
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"math"
+	"time"
+)
+
+var (
+	PaymentTableName = "payment"
+)
+
+// Payment methods.
+const (
+	PaymentMethodCash        = "cash"
+	PaymentMethodCard        = "card"
+	PaymentMethodTransfer    = "transfer"
+	PaymentMethodStoreCredit = "store_credit"
+	PaymentMethodLoyalty     = "loyalty_points"
+)
+
+// Amounts lower than a cent are considered equal.
+const cent = 0.005
+
+// @Description Bill payment.
+type Payment struct {
+	Id        uint64    `xorm:"pk autoincr" json:"id"`
+	BillId    uint64    `xorm:"index" json:"bill_id"`
+	Method    string    `xorm:"index not null" json:"method"`
+	Amount    float64   `xorm:"not null default 0" json:"amount"`
+	Tendered  float64   `xorm:"not null default 0" json:"tendered"`
+	Change    float64   `xorm:"not null default 0" json:"change"`
+	Reference string    `json:"reference"`
+	Created   time.Time `xorm:"created" json:"created"`
+	Updated   time.Time `xorm:"updated" json:"updated"`
+}
+
+func (p *Payment) TableName() string {
+	return PaymentTableName
+}
+
+// In order to filter the payments by date and headquarter we need to
+// do a join between payment and bill in the xorm way.
+type PaymentBill struct {
+	Payment `xorm:"extends"`
+	Bill    `xorm:"extends"`
+}
+
+// @Description Validate the payments cover the total and calculate the cash
+// change. Only cash payments can exceed the total, the excess is returned as
+// change so the payment amounts always sum the total.
+// @Param payments Payments.
+// @Param total Bill total.
+func settlePayments(payments []*Payment, total float64) error {
+	var paid float64
+	for _, payment := range payments {
+		switch payment.Method {
+		case PaymentMethodCash, PaymentMethodCard, PaymentMethodTransfer, PaymentMethodStoreCredit, PaymentMethodLoyalty:
+		default:
+			return &ValidationError{Message: fmt.Sprintf("Payment method %s is not supported.", payment.Method)}
+		}
+		if payment.Amount < 0 {
+			return &ValidationError{Message: "Payment amounts can not be negative."}
+		}
+
+		// Only cash has tendered amount.
+		if payment.Method != PaymentMethodCash || payment.Tendered < payment.Amount {
+			payment.Tendered = payment.Amount
+		}
+		paid += payment.Amount
+	}
+
+	// Validate total.
+	if paid < total-cent {
+		return &ConflictError{Message: fmt.Sprintf("Payments %.2f do not cover the bill total %.2f.", paid, total)}
+	}
+
+	// Return the excess as cash change.
+	excess := paid - total
+	for _, payment := range payments {
+		if payment.Method == PaymentMethodCash && excess > cent {
+			returned := math.Min(excess, payment.Amount)
+			payment.Amount -= returned
+			excess -= returned
+		}
+		payment.Change = payment.Tendered - payment.Amount
+	}
+	if excess > cent {
+		return &ConflictError{Message: fmt.Sprintf("Only cash payments can exceed the bill total %.2f.", total)}
+	}
+
+	return nil
+}
+
+// @Description Settle the payments again with a new total. The cash
+// payments return to their tendered amount, so the change absorbs the total
+// decrease. The card, transfer, store credit and loyalty payments were already
+// charged, they can not be reduced and the difference must be refunded with a
+// credit note.
+// @Param payments Payments.
+// @Param total New bill total.
+func resettlePayments(payments []*Payment, total float64) error {
+	var charged float64
+	for _, payment := range payments {
+		if payment.Method == PaymentMethodCash {
+			payment.Amount = payment.Tendered
+			continue
+		}
+		charged += payment.Amount
+	}
+	if charged > total+cent {
+		return &ValidationError{Message: fmt.Sprintf("Non cash payments %.2f exceed the new bill total %.2f, issue a credit note to refund the difference.", charged, total)}
+	}
+
+	return settlePayments(payments, total)
+}
+
+type PaymentDao struct {
+	Dao
+}
+
+func NewPaymentDao(schema string) *PaymentDao {
+	d := new(PaymentDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Param billId Bill Id.
+func (d *PaymentDao) FindByBill(billId uint64) ([]*Payment, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	payments := make([]*Payment, 0)
+	err := engine.Where("bill_id = ?", billId).Asc("id").Find(&payments)
+
+	return payments, err
+}
+
+// @Description Get revenue by payment method and dates.
+// @Param start Start time.
+// @Param end End time.
+func (d *PaymentDao) RevenueByMethodAndDates(start, end time.Time) (map[string]float64, error) {
+	return d.revenueByMethod(nil, start, end)
+}
+
+// @Description Get revenue by payment method, headquarter ID and dates.
+// @Param headquarterID Headquarter ID.
+// @Param start Start time.
+// @Param end End time.
+func (d *PaymentDao) RevenueByMethodAndHeadquarterIDAndDates(headquarterID uint64, start, end time.Time) (map[string]float64, error) {
+	return d.revenueByMethod(&headquarterID, start, end)
+}
+
+// @Param headquarterID Optional headquarter ID.
+// @Param start Start time.
+// @Param end End time.
+func (d *PaymentDao) revenueByMethod(headquarterID *uint64, start, end time.Time) (map[string]float64, error) {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("SELECT * FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(PaymentTableName)
+	sql.WriteString(" pm INNER JOIN ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b ON pm.bill_id = b.id AND b.status <> '")
+	sql.WriteString(BillStatusVoided)
+	sql.WriteString("'")
+	if headquarterID != nil {
+		sql.WriteString(" AND b.headquarter_id = ")
+		sql.WriteString(fmt.Sprintf("%v", *headquarterID))
+	}
+	sql.WriteString(" WHERE pm.created >= '")
+	sql.WriteString(start.UTC().Format(stringutil.UTCFormat))
+	sql.WriteString("' AND pm.created <= '")
+	sql.WriteString(end.UTC().Format(stringutil.UTCFormat))
+	sql.WriteString("'")
+
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+	payments := make([]*PaymentBill, 0)
+
+	// Execute sentence.
+	err := engine.Sql(sql.String()).AllCols().Find(&payments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Golang is faster than PostgreSQL SGBD so here we group by method.
+	revenue := make(map[string]float64)
+	for _, payment := range payments {
+		revenue[payment.Payment.Method] += payment.Payment.Amount
+	}
+
+	return revenue, nil
+}
+
+// @Description Settle again the payments of an edited bill with its new
+// total. The cash tendered is kept and the change recalculated, the edit is
+// rejected when the payments no longer cover the total or the non cash
+// payments exceed it. Bills without recorded payments are not settled.
+// @Param session Transaction session.
+// @Param bill Bill with the new total.
+func (d *PaymentDao) resettle(session *xorm.Session, bill *Bill) error {
+	payments := make([]*Payment, 0)
+	err := session.NoCache().Where("bill_id = ?", bill.Id).Asc("id").Find(&payments)
+	if err != nil {
+		return err
+	}
+	if len(payments) == 0 {
+		return nil
+	}
+
+	err = resettlePayments(payments, bill.Total)
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments {
+		_, err = session.ID(payment.Id).Cols("amount", "tendered", "change").Update(payment)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
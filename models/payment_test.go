@@ -0,0 +1,173 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSettlePayments(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    float64
+		payments []*Payment
+		amounts  []float64
+		change   []float64
+		err      error
+	}{
+		{
+			name:     "exact card",
+			total:    50,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 50}},
+			amounts:  []float64{50},
+			change:   []float64{0},
+		},
+		{
+			name:     "cash change",
+			total:    42.5,
+			payments: []*Payment{{Method: PaymentMethodCash, Amount: 50, Tendered: 50}},
+			amounts:  []float64{42.5},
+			change:   []float64{7.5},
+		},
+		{
+			name:     "cash tendered without amount excess",
+			total:    20,
+			payments: []*Payment{{Method: PaymentMethodCash, Amount: 20, Tendered: 25}},
+			amounts:  []float64{20},
+			change:   []float64{5},
+		},
+		{
+			name:     "split tender with cash change",
+			total:    100,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 60}, {Method: PaymentMethodCash, Amount: 50, Tendered: 50}},
+			amounts:  []float64{60, 40},
+			change:   []float64{0, 10},
+		},
+		{
+			name:     "underpayment within a cent",
+			total:    10.004,
+			payments: []*Payment{{Method: PaymentMethodTransfer, Amount: 10}},
+			amounts:  []float64{10},
+			change:   []float64{0},
+		},
+		{
+			name:     "underpayment",
+			total:    10,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 9.99}},
+			err:      &ConflictError{},
+		},
+		{
+			name:     "card overpayment",
+			total:    10,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 12}},
+			err:      &ConflictError{},
+		},
+		{
+			name:     "overpayment over the cash",
+			total:    10,
+			payments: []*Payment{{Method: PaymentMethodStoreCredit, Amount: 12}, {Method: PaymentMethodCash, Amount: 1, Tendered: 1}},
+			err:      &ConflictError{},
+		},
+		{
+			name:     "negative amount",
+			total:    0,
+			payments: []*Payment{{Method: PaymentMethodCash, Amount: -1}},
+			err:      &ValidationError{},
+		},
+		{
+			name:     "unsupported method",
+			total:    10,
+			payments: []*Payment{{Method: "cheque", Amount: 10}},
+			err:      &ValidationError{},
+		},
+	}
+
+	for _, test := range tests {
+		err := settlePayments(test.payments, test.total)
+		if test.err != nil {
+			if reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+				t.Errorf("%s: expected a %T, got %v.", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		for i, payment := range test.payments {
+			if math.Abs(payment.Amount-test.amounts[i]) > 1e-9 || math.Abs(payment.Change-test.change[i]) > 1e-9 {
+				t.Errorf("%s: payment %d amount %.2f change %.2f, expected %.2f and %.2f.", test.name, i, payment.Amount, payment.Change, test.amounts[i], test.change[i])
+			}
+		}
+	}
+}
+
+func TestResettlePayments(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    float64
+		payments []*Payment
+		amounts  []float64
+		change   []float64
+		err      error
+	}{
+		{
+			name:     "cash change after a line is removed",
+			total:    30,
+			payments: []*Payment{{Method: PaymentMethodCash, Amount: 42.5, Tendered: 50, Change: 7.5}},
+			amounts:  []float64{30},
+			change:   []float64{20},
+		},
+		{
+			name:     "cash change after a line is added",
+			total:    48,
+			payments: []*Payment{{Method: PaymentMethodCash, Amount: 42.5, Tendered: 50, Change: 7.5}},
+			amounts:  []float64{48},
+			change:   []float64{2},
+		},
+		{
+			name:     "split tender after a line is removed",
+			total:    70,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 60}, {Method: PaymentMethodCash, Amount: 40, Tendered: 50, Change: 10}},
+			amounts:  []float64{60, 10},
+			change:   []float64{0, 40},
+		},
+		{
+			name:     "card payment above the total after a line is removed",
+			total:    30,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 50}},
+			err:      &ValidationError{},
+		},
+		{
+			name:     "card and cash above the total after a line is removed",
+			total:    50,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 60}, {Method: PaymentMethodCash, Amount: 40, Tendered: 40}},
+			err:      &ValidationError{},
+		},
+		{
+			name:     "payments under the total after a line is added",
+			total:    60,
+			payments: []*Payment{{Method: PaymentMethodCard, Amount: 50}},
+			err:      &ConflictError{},
+		},
+	}
+
+	for _, test := range tests {
+		err := resettlePayments(test.payments, test.total)
+		if test.err != nil {
+			if reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+				t.Errorf("%s: expected a %T, got %v.", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		for i, payment := range test.payments {
+			if math.Abs(payment.Amount-test.amounts[i]) > 1e-9 || math.Abs(payment.Change-test.change[i]) > 1e-9 {
+				t.Errorf("%s: payment %d amount %.2f change %.2f, expected %.2f and %.2f.", test.name, i, payment.Amount, payment.Change, test.amounts[i], test.change[i])
+			}
+		}
+	}
+}
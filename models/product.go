@@ -10,14 +10,17 @@ var (
 )
 
 type Product struct {
-	Id      uint64    `xorm:"pk autoincr" json:"id"`
-	Name    string    `xorm:"not null" json:"name"`
-	Brand   string    `json:"brand"`
-	Color   string    `json:"color"`
-	Price   float64   `xorm:"not null" json:"price"`
-	Cost    float64   `xorm:"not null" json:"cost"`
-	Created time.Time `xorm:"created" json:"created"`
-	Updated time.Time `xorm:"updated" json:"updated"`
+	Id          uint64    `xorm:"pk autoincr" json:"id"`
+	Name        string    `xorm:"not null" json:"name"`
+	Brand       string    `json:"brand"`
+	Color       string    `json:"color"`
+	Price       float64   `xorm:"not null" json:"price"`
+	Cost        float64   `xorm:"not null" json:"cost"`
+	TaxRateId   uint64    `xorm:"index" json:"tax_rate_id"`
+	TaxIncluded bool      `xorm:"not null default false" json:"tax_included"`
+	ProviderId  uint64    `xorm:"index not null default 0" json:"provider_id"`
+	Created     time.Time `xorm:"created" json:"created"`
+	Updated     time.Time `xorm:"updated" json:"updated"`
 }
 
 func (p *Product) TableName() string {
@@ -0,0 +1,380 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PromotionBuyXGetY   = "buy_x_get_y"
+	PromotionNForPrice  = "n_for_price"
+	PromotionPercentage = "percentage"
+
+	// Happy hour times layout.
+	promotionTimeLayout = "15:04"
+)
+
+var (
+	PromotionTableName = "promotion"
+)
+
+// @Description Promotion rule applied automatically to the bill lines. The
+// rule applies to the products matching every given filter, inside the dates
+// and the daily happy hour window when they are given.
+type Promotion struct {
+	Id            uint64    `xorm:"pk autoincr" json:"id"`
+	Name          string    `xorm:"not null" json:"name"`
+	Type          string    `xorm:"not null" json:"type"`
+	Active        bool      `xorm:"not null default true" json:"active"`
+	HeadquarterId uint64    `xorm:"index not null default 0" json:"headquarter_id"`
+	ProductId     uint64    `xorm:"not null default 0" json:"product_id"`
+	Brand         string    `json:"brand"`
+	Color         string    `json:"color"`
+	BuyAmount     uint64    `xorm:"not null default 0" json:"buy_amount"`
+	GetAmount     uint64    `xorm:"not null default 0" json:"get_amount"`
+	Price         float64   `xorm:"not null default 0" json:"price"`
+	Rate          float64   `xorm:"not null default 0" json:"rate"`
+	Starts        time.Time `xorm:"null" json:"starts"`
+	Ends          time.Time `xorm:"null" json:"ends"`
+	StartTime     string    `json:"start_time"`
+	EndTime       string    `json:"end_time"`
+	Created       time.Time `xorm:"created" json:"created"`
+	Updated       time.Time `xorm:"updated" json:"updated"`
+}
+
+func (p *Promotion) TableName() string {
+	return PromotionTableName
+}
+
+// @Description Validate the promotion rule fields, every type requires the
+// amounts its rule is built from.
+func (p *Promotion) Validate() error {
+	switch p.Type {
+	case PromotionBuyXGetY:
+		if p.BuyAmount == 0 || p.GetAmount == 0 {
+			return &ValidationError{Message: "Buy X get Y promotions require a buy amount and a get amount."}
+		}
+	case PromotionNForPrice:
+		if p.BuyAmount == 0 || p.Price <= 0 {
+			return &ValidationError{Message: "N for price promotions require a buy amount and a price."}
+		}
+	case PromotionPercentage:
+	case "":
+		return &ValidationError{Message: "Promotion type can not be empty."}
+	default:
+		return &ValidationError{Message: fmt.Sprintf("Promotion type %s is not valid.", p.Type)}
+	}
+
+	if p.Rate < 0 || p.Rate > 100 {
+		return &ValidationError{Message: fmt.Sprintf("Promotion rate %.2f must be between 0 and 100.", p.Rate)}
+	}
+	if p.Price < 0 {
+		return &ValidationError{Message: fmt.Sprintf("Promotion price %.2f can not be negative.", p.Price)}
+	}
+
+	// Validate the happy hour window.
+	for _, t := range []string{p.StartTime, p.EndTime} {
+		if len(t) == 0 {
+			continue
+		}
+		if _, err := time.Parse(promotionTimeLayout, t); err != nil {
+			return &ValidationError{Message: fmt.Sprintf("Promotion time %s must have the HH:MM format.", t)}
+		}
+	}
+
+	return nil
+}
+
+// @Description Whether the promotion is running at the given time.
+// @Param now Time.
+func (p *Promotion) activeAt(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if !p.Starts.IsZero() && now.Before(p.Starts) {
+		return false
+	}
+	if !p.Ends.IsZero() && now.After(p.Ends) {
+		return false
+	}
+
+	// Validate the happy hour window, it can go through midnight.
+	if len(p.StartTime) == 0 || len(p.EndTime) == 0 {
+		return true
+	}
+	clock := now.Format(promotionTimeLayout)
+	if p.StartTime <= p.EndTime {
+		return clock >= p.StartTime && clock < p.EndTime
+	}
+	return clock >= p.StartTime || clock < p.EndTime
+}
+
+// @Description Whether the product matches the promotion filters.
+// @Param product Product.
+func (p *Promotion) matches(product *Product) bool {
+	if p.ProductId > 0 && p.ProductId != product.Id {
+		return false
+	}
+	if len(p.Brand) > 0 && !strings.EqualFold(p.Brand, product.Brand) {
+		return false
+	}
+	if len(p.Color) > 0 && !strings.EqualFold(p.Color, product.Color) {
+		return false
+	}
+	return true
+}
+
+// @Description Discount of the promotion over every matching line of a bill,
+// so bundles can span several lines and products. Buy X get Y gives the
+// cheapest units for free and N for price groups the most expensive units.
+// @Param sales Matching sales with the snapshot price.
+func (p *Promotion) discount(sales []*Sale) []float64 {
+	discounts := make([]float64, len(sales))
+	var units uint64
+	for _, sale := range sales {
+		units += sale.Amount
+	}
+
+	switch p.Type {
+	case PromotionBuyXGetY:
+		// Every X + Y units the Y cheapest units are free.
+		if p.BuyAmount == 0 || p.GetAmount == 0 {
+			return discounts
+		}
+		free := units / (p.BuyAmount + p.GetAmount) * p.GetAmount
+		for _, i := range byUnitPrice(sales, true) {
+			taken := minAmount(free, sales[i].Amount)
+			discounts[i] = float64(taken) * sales[i].UnitPrice
+			free -= taken
+		}
+	case PromotionNForPrice:
+		// Every N units cost the promotion price.
+		if p.BuyAmount == 0 || p.Price <= 0 {
+			return discounts
+		}
+		groups := units / p.BuyAmount
+		grouped := groups * p.BuyAmount
+		var list float64
+		for _, i := range byUnitPrice(sales, false) {
+			taken := minAmount(grouped, sales[i].Amount)
+			discounts[i] = float64(taken) * sales[i].UnitPrice
+			list += discounts[i]
+			grouped -= taken
+		}
+
+		// Distribute the saving between the grouped units.
+		saving := math.Max(0, list-float64(groups)*p.Price)
+		for i := range discounts {
+			if list > 0 {
+				discounts[i] *= saving / list
+			}
+		}
+	case PromotionPercentage:
+		for i, sale := range sales {
+			discounts[i] = float64(sale.Amount) * sale.UnitPrice * p.Rate / 100
+		}
+	}
+
+	return discounts
+}
+
+// @Description Indexes of the sales sorted by unit price.
+// @Param sales Sales.
+// @Param ascending Cheapest first.
+func byUnitPrice(sales []*Sale, ascending bool) []int {
+	indexes := make([]int, len(sales))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		if ascending {
+			return sales[indexes[a]].UnitPrice < sales[indexes[b]].UnitPrice
+		}
+		return sales[indexes[a]].UnitPrice > sales[indexes[b]].UnitPrice
+	})
+	return indexes
+}
+
+func minAmount(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type PromotionDao struct {
+	Dao
+}
+
+func NewPromotionDao(schema string) *PromotionDao {
+	d := new(PromotionDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Validate and insert the promotion.
+// @Param promotion Promotion.
+func (d *PromotionDao) Create(promotion *Promotion) error {
+	err := promotion.Validate()
+	if err != nil {
+		return err
+	}
+
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	_, err = engine.Insert(promotion)
+
+	return err
+}
+
+// @Description Update the given promotion fields, the resulting promotion is
+// validated before the update is committed.
+// @Param promotion Promotion fields to update.
+func (d *PromotionDao) Update(promotion *Promotion) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		found, err := session.NoCache().ForUpdate().ID(promotion.Id).Exist(new(Promotion))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Promotion %d does not exist.", promotion.Id)}
+		}
+
+		_, err = session.ID(promotion.Id).Update(promotion)
+		if err != nil {
+			return err
+		}
+
+		// Validate the updated promotion.
+		_, err = session.NoCache().ID(promotion.Id).Get(promotion)
+		if err != nil {
+			return err
+		}
+
+		return promotion.Validate()
+	})
+}
+
+// @Description Find the promotions of the headquarter running at the given
+// time, including the promotions of every headquarter.
+// @Param session Session.
+// @Param headquarterId Headquarter Id.
+// @Param now Time.
+func (d *PromotionDao) FindActive(session *xorm.Session, headquarterId uint64, now time.Time) ([]*Promotion, error) {
+	promotions := make([]*Promotion, 0)
+	err := session.NoCache().Where("active = ?", true).
+		And("(headquarter_id = 0 OR headquarter_id = ?)", headquarterId).
+		Asc("id").Find(&promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter by dates and happy hours.
+	active := make([]*Promotion, 0)
+	for _, promotion := range promotions {
+		if promotion.activeAt(now) {
+			active = append(active, promotion)
+		}
+	}
+
+	return active, nil
+}
+
+// @Description Apply the promotions running at the given time over the bill
+// lines. The rules are evaluated over every line, the promotion giving the
+// biggest discount applies first and each line takes part in one promotion.
+// The sale lines must have the product snapshot.
+// @Param session Session.
+// @Param schema Customer schema.
+// @Param headquarterId Headquarter Id.
+// @Param sales Bill sales.
+// @Param now Time.
+func applyPromotions(session *xorm.Session, schema string, headquarterId uint64, sales []*Sale, now time.Time) error {
+	for _, sale := range sales {
+		sale.PromotionId = 0
+		sale.PromotionDiscount = 0
+	}
+
+	promotions, err := NewPromotionDao(schema).FindActive(session, headquarterId, now)
+	if err != nil {
+		return err
+	}
+	if len(promotions) == 0 {
+		return nil
+	}
+
+	// Get the product filters.
+	products := make([]*Product, len(sales))
+	for i, sale := range sales {
+		product := new(Product)
+		found, err := session.NoCache().ID(sale.ProductId).Get(product)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", sale.ProductId)}
+		}
+		products[i] = product
+	}
+
+	pickBestPromotions(promotions, products, sales)
+
+	return nil
+}
+
+// @Description Apply the promotion giving the biggest discount over the lines
+// without promotion until no promotion discounts.
+// @Param promotions Running promotions.
+// @Param products Product of every sale.
+// @Param sales Bill sales.
+func pickBestPromotions(promotions []*Promotion, products []*Product, sales []*Sale) {
+	promoted := make([]bool, len(sales))
+	used := make([]bool, len(promotions))
+	for {
+		best, bestTotal := -1, 0.0
+		var bestLines []int
+		var bestDiscounts []float64
+		for j, promotion := range promotions {
+			if used[j] {
+				continue
+			}
+
+			// Get the matching lines without promotion.
+			lines := make([]int, 0)
+			matching := make([]*Sale, 0)
+			for i, sale := range sales {
+				if !promoted[i] && promotion.matches(products[i]) {
+					lines = append(lines, i)
+					matching = append(matching, sale)
+				}
+			}
+
+			discounts := promotion.discount(matching)
+			var total float64
+			for k, sale := range matching {
+				discounts[k] = math.Min(discounts[k], float64(sale.Amount)*sale.UnitPrice)
+				total += discounts[k]
+			}
+			if total > bestTotal {
+				best, bestTotal, bestLines, bestDiscounts = j, total, lines, discounts
+			}
+		}
+		if best < 0 {
+			return
+		}
+
+		// Every matching line takes part in the promotion.
+		used[best] = true
+		for k, i := range bestLines {
+			promoted[i] = true
+			sales[i].PromotionId = promotions[best].Id
+			sales[i].PromotionDiscount = bestDiscounts[k]
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPromotionActiveAt(t *testing.T) {
+	day := time.Date(2018, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name      string
+		promotion Promotion
+		now       time.Time
+		expected  bool
+	}{
+		{name: "always", promotion: Promotion{Active: true}, now: at(12, 0), expected: true},
+		{name: "inactive", promotion: Promotion{}, now: at(12, 0)},
+		{name: "before the start", promotion: Promotion{Active: true, Starts: at(13, 0)}, now: at(12, 0)},
+		{name: "after the end", promotion: Promotion{Active: true, Ends: at(11, 0)}, now: at(12, 0)},
+		{name: "inside the dates", promotion: Promotion{Active: true, Starts: at(11, 0), Ends: at(13, 0)}, now: at(12, 0), expected: true},
+		{name: "happy hour start", promotion: Promotion{Active: true, StartTime: "17:00", EndTime: "19:00"}, now: at(17, 0), expected: true},
+		{name: "happy hour end", promotion: Promotion{Active: true, StartTime: "17:00", EndTime: "19:00"}, now: at(19, 0)},
+		{name: "before happy hour", promotion: Promotion{Active: true, StartTime: "17:00", EndTime: "19:00"}, now: at(16, 59)},
+		{name: "happy hour through midnight late", promotion: Promotion{Active: true, StartTime: "22:00", EndTime: "02:00"}, now: at(23, 30), expected: true},
+		{name: "happy hour through midnight early", promotion: Promotion{Active: true, StartTime: "22:00", EndTime: "02:00"}, now: at(1, 30), expected: true},
+		{name: "outside happy hour through midnight", promotion: Promotion{Active: true, StartTime: "22:00", EndTime: "02:00"}, now: at(12, 0)},
+		{name: "happy hour outside the dates", promotion: Promotion{Active: true, Ends: day, StartTime: "17:00", EndTime: "19:00"}, now: at(18, 0)},
+	}
+
+	for _, test := range tests {
+		if active := test.promotion.activeAt(test.now); active != test.expected {
+			t.Errorf("%s: active %t, expected %t.", test.name, active, test.expected)
+		}
+	}
+}
+
+func TestPromotionValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion Promotion
+		valid     bool
+	}{
+		{name: "buy 2 get 1", promotion: Promotion{Type: PromotionBuyXGetY, BuyAmount: 2, GetAmount: 1}, valid: true},
+		{name: "buy 0 get 1", promotion: Promotion{Type: PromotionBuyXGetY, GetAmount: 1}},
+		{name: "buy 2 get 0", promotion: Promotion{Type: PromotionBuyXGetY, BuyAmount: 2}},
+		{name: "3 for 20", promotion: Promotion{Type: PromotionNForPrice, BuyAmount: 3, Price: 20}, valid: true},
+		{name: "0 for 20", promotion: Promotion{Type: PromotionNForPrice, Price: 20}},
+		{name: "3 for free", promotion: Promotion{Type: PromotionNForPrice, BuyAmount: 3}},
+		{name: "percentage", promotion: Promotion{Type: PromotionPercentage, Rate: 10}, valid: true},
+		{name: "percentage over 100", promotion: Promotion{Type: PromotionPercentage, Rate: 110}},
+		{name: "empty type", promotion: Promotion{BuyAmount: 2, GetAmount: 1}},
+		{name: "unknown type", promotion: Promotion{Type: "free"}},
+		{name: "happy hour", promotion: Promotion{Type: PromotionPercentage, Rate: 10, StartTime: "17:00", EndTime: "19:00"}, valid: true},
+		{name: "invalid happy hour", promotion: Promotion{Type: PromotionPercentage, Rate: 10, StartTime: "5pm"}},
+	}
+
+	for _, test := range tests {
+		err := test.promotion.Validate()
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("%s: expected a validation error, got %v.", test.name, err)
+		}
+	}
+}
+
+func TestPromotionDiscount(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion Promotion
+		sales     []*Sale
+		expected  []float64
+	}{
+		{
+			name:      "buy 2 get 1",
+			promotion: Promotion{Type: PromotionBuyXGetY, BuyAmount: 2, GetAmount: 1},
+			sales:     []*Sale{{Amount: 7, UnitPrice: 10}},
+			expected:  []float64{20},
+		},
+		{
+			name:      "buy 2 get 1 over several lines gives the cheapest",
+			promotion: Promotion{Type: PromotionBuyXGetY, BuyAmount: 2, GetAmount: 1},
+			sales:     []*Sale{{Amount: 1, UnitPrice: 10}, {Amount: 1, UnitPrice: 4}, {Amount: 1, UnitPrice: 10}},
+			expected:  []float64{0, 4, 0},
+		},
+		{
+			name:      "3 for 20 bundle of different products",
+			promotion: Promotion{Type: PromotionNForPrice, BuyAmount: 3, Price: 20},
+			sales:     []*Sale{{Amount: 1, UnitPrice: 12}, {Amount: 2, UnitPrice: 9}},
+			expected:  []float64{12 * 10.0 / 30, 18 * 10.0 / 30},
+		},
+		{
+			name:      "2 for 15 groups the most expensive units",
+			promotion: Promotion{Type: PromotionNForPrice, BuyAmount: 2, Price: 15},
+			sales:     []*Sale{{Amount: 1, UnitPrice: 5}, {Amount: 2, UnitPrice: 10}},
+			expected:  []float64{0, 5},
+		},
+		{
+			name:      "price over the list price",
+			promotion: Promotion{Type: PromotionNForPrice, BuyAmount: 2, Price: 30},
+			sales:     []*Sale{{Amount: 2, UnitPrice: 10}},
+			expected:  []float64{0},
+		},
+		{
+			name:      "percentage",
+			promotion: Promotion{Type: PromotionPercentage, Rate: 25},
+			sales:     []*Sale{{Amount: 2, UnitPrice: 10}, {Amount: 1, UnitPrice: 8}},
+			expected:  []float64{5, 2},
+		},
+		{
+			name:      "incomplete rule",
+			promotion: Promotion{Type: PromotionBuyXGetY},
+			sales:     []*Sale{{Amount: 3, UnitPrice: 10}},
+			expected:  []float64{0},
+		},
+		{
+			name:      "buy 0 get 1",
+			promotion: Promotion{Type: PromotionBuyXGetY, GetAmount: 1},
+			sales:     []*Sale{{Amount: 3, UnitPrice: 10}},
+			expected:  []float64{0},
+		},
+		{
+			name:      "buy 2 get 0",
+			promotion: Promotion{Type: PromotionBuyXGetY, BuyAmount: 2},
+			sales:     []*Sale{{Amount: 3, UnitPrice: 10}},
+			expected:  []float64{0},
+		},
+		{
+			name:      "0 for 20",
+			promotion: Promotion{Type: PromotionNForPrice, Price: 20},
+			sales:     []*Sale{{Amount: 3, UnitPrice: 10}},
+			expected:  []float64{0},
+		},
+		{
+			name:      "2 for free",
+			promotion: Promotion{Type: PromotionNForPrice, BuyAmount: 2},
+			sales:     []*Sale{{Amount: 3, UnitPrice: 10}},
+			expected:  []float64{0},
+		},
+	}
+
+	for _, test := range tests {
+		discounts := test.promotion.discount(test.sales)
+		for i, discount := range discounts {
+			if math.Abs(discount-test.expected[i]) > 1e-9 {
+				t.Errorf("%s: line %d discount %.4f, expected %.4f.", test.name, i, discount, test.expected[i])
+			}
+		}
+	}
+}
+
+func TestPickBestPromotions(t *testing.T) {
+	products := []*Product{{Id: 1, Brand: "Acme"}, {Id: 2, Brand: "Acme"}, {Id: 3, Brand: "Other"}}
+	sales := []*Sale{{ProductId: 1, Amount: 1, UnitPrice: 10}, {ProductId: 2, Amount: 2, UnitPrice: 10}, {ProductId: 3, Amount: 1, UnitPrice: 10}}
+	promotions := []*Promotion{
+		{Id: 1, Type: PromotionPercentage, Rate: 10},
+		{Id: 2, Type: PromotionBuyXGetY, Brand: "acme", BuyAmount: 2, GetAmount: 1},
+	}
+
+	// The Acme bundle discounts more than the percentage, the other product
+	// keeps the percentage.
+	pickBestPromotions(promotions, products, sales)
+	expected := []struct {
+		promotionId uint64
+		discount    float64
+	}{{2, 10}, {2, 0}, {1, 1}}
+	for i, sale := range sales {
+		if sale.PromotionId != expected[i].promotionId || math.Abs(sale.PromotionDiscount-expected[i].discount) > 1e-9 {
+			t.Errorf("Line %d promotion %d discount %.2f, expected %d and %.2f.", i, sale.PromotionId, sale.PromotionDiscount, expected[i].promotionId, expected[i].discount)
+		}
+	}
+}
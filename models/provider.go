@@ -9,12 +9,13 @@ var (
 )
 
 type Provider struct {
-	Id      uint64    `xorm:"pk autoincr" json:"id"`
-	Name    string    `xorm:"not null unique" json:"name"`
-	Address string    `json:"address"`
-	Phone   string    `json:"phone"`
-	Created time.Time `xorm:"created" json:"created"`
-	Updated time.Time `xorm:"updated" json:"updated"`
+	Id           uint64    `xorm:"pk autoincr" json:"id"`
+	Name         string    `xorm:"not null unique" json:"name"`
+	Address      string    `json:"address"`
+	Phone        string    `json:"phone"`
+	LeadTimeDays uint      `xorm:"not null default 0" json:"lead_time_days"`
+	Created      time.Time `xorm:"created" json:"created"`
+	Updated      time.Time `xorm:"updated" json:"updated"`
 }
 
 func (p *Provider) TableName() string {
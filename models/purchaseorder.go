@@ -0,0 +1,383 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	PurchaseOrderTableName     = "purchase_order"
+	PurchaseOrderLineTableName = "purchase_order_line"
+)
+
+// Purchase order status.
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// @Description Products ordered to a provider for a headquarter.
+type PurchaseOrder struct {
+	Id            uint64               `xorm:"pk autoincr" json:"id"`
+	ProviderId    uint64               `xorm:"index" json:"provider_id"`
+	HeadquarterId uint64               `xorm:"index" json:"headquarter_id"`
+	Status        string               `xorm:"index not null" json:"status"`
+	Notes         string               `json:"notes"`
+	UserId        string               `json:"user_id"`
+	SentBy        string               `json:"sent_by"`
+	Sent          time.Time            `xorm:"null" json:"sent"`
+	ReceivedBy    string               `json:"received_by"`
+	Received      time.Time            `xorm:"null" json:"received"`
+	CancelledBy   string               `json:"cancelled_by"`
+	Cancelled     time.Time            `xorm:"null" json:"cancelled"`
+	Lines         []*PurchaseOrderLine `xorm:"-" json:"lines"`
+	Created       time.Time            `xorm:"created" json:"created"`
+	Updated       time.Time            `xorm:"updated" json:"updated"`
+}
+
+func (p *PurchaseOrder) TableName() string {
+	return PurchaseOrderTableName
+}
+
+// @Description Ordered product at the unit cost agreed with the provider.
+type PurchaseOrderLine struct {
+	Id              uint64    `xorm:"pk autoincr" json:"id"`
+	PurchaseOrderId uint64    `xorm:"index" json:"purchase_order_id"`
+	ProductId       uint64    `xorm:"index" json:"product_id"`
+	Amount          uint64    `xorm:"not null" json:"amount"`
+	Received        uint64    `xorm:"not null default 0" json:"received"`
+	UnitCost        float64   `xorm:"not null default 0" json:"unit_cost"`
+	Expected        time.Time `xorm:"null" json:"expected"`
+	Created         time.Time `xorm:"created" json:"created"`
+	Updated         time.Time `xorm:"updated" json:"updated"`
+}
+
+func (p *PurchaseOrderLine) TableName() string {
+	return PurchaseOrderLineTableName
+}
+
+type PurchaseOrderDao struct {
+	Dao
+}
+
+func NewPurchaseOrderDao(schema string) *PurchaseOrderDao {
+	d := new(PurchaseOrderDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Create the purchase order draft with its lines.
+// @Param order Purchase order with its lines.
+func (d *PurchaseOrderDao) Create(order *PurchaseOrder) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		return d.create(session, order)
+	})
+}
+
+// @Description Get the purchase order with its lines.
+// @Param orderId Purchase order Id.
+func (d *PurchaseOrderDao) Read(orderId uint64) (*PurchaseOrder, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	order := new(PurchaseOrder)
+	found, err := session.NoCache().ID(orderId).Get(order)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Purchase order %d does not exist.", orderId)}
+	}
+
+	order.Lines, err = d.findLines(session, orderId)
+
+	return order, err
+}
+
+// @Param providerId Provider Id, 0 for every provider.
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+// @Param status Purchase order status, empty for every status.
+func (d *PurchaseOrderDao) FindByProviderAndHeadquarterAndStatus(providerId, headquarterId uint64, status string) ([]*PurchaseOrder, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Desc("id")
+	if providerId > 0 {
+		session = session.And("provider_id = ?", providerId)
+	}
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+	if len(status) > 0 {
+		session = session.And("status = ?", status)
+	}
+
+	orders := make([]*PurchaseOrder, 0)
+	err := session.Find(&orders)
+
+	return orders, err
+}
+
+// @Description Send the purchase order draft to the provider.
+// @Param orderId Purchase order Id.
+// @Param userId User sending the purchase order.
+func (d *PurchaseOrderDao) Send(orderId uint64, userId string) (*PurchaseOrder, error) {
+	var order *PurchaseOrder
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		order, err = d.readForUpdate(session, orderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != PurchaseOrderStatusDraft {
+			return &ConflictError{Message: fmt.Sprintf("Purchase order %d is %s.", orderId, order.Status)}
+		}
+
+		order.Status = PurchaseOrderStatusSent
+		order.SentBy = userId
+		order.Sent = time.Now()
+		_, err = session.ID(order.Id).Cols("status", "sent_by", "sent").Update(order)
+
+		return err
+	})
+
+	return order, err
+}
+
+// @Description Receive purchase order lines. Every received line is recorded
+// as a catering of the purchase order headquarter increasing its stock. The
+// purchase order stays partially received until every line is received or it
+// is closed.
+// @Param orderId Purchase order Id.
+// @Param lines Received lines by product, with the received amount.
+// @Param userId User receiving the purchase order.
+// @Param notes Receipt notes.
+// @Param close Close the purchase order.
+func (d *PurchaseOrderDao) Receive(orderId uint64, lines []*PurchaseOrderLine, userId, notes string, close bool) (*PurchaseOrder, error) {
+	var order *PurchaseOrder
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		order, err = d.readForUpdate(session, orderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != PurchaseOrderStatusSent && order.Status != PurchaseOrderStatusPartiallyReceived {
+			return &ConflictError{Message: fmt.Sprintf("Purchase order %d is %s.", orderId, order.Status)}
+		}
+
+		// Index the purchase order lines.
+		orderLines := make(map[uint64]*PurchaseOrderLine)
+		for _, line := range order.Lines {
+			orderLines[line.ProductId] = line
+		}
+
+		// Record the caterings.
+		cateringDao := NewCateringDao(d.GetSchema())
+		for _, received := range lines {
+			line, ok := orderLines[received.ProductId]
+			if !ok {
+				return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist in purchase order %d.", received.ProductId, orderId)}
+			}
+			if received.Received == 0 {
+				continue
+			}
+			if line.Received+received.Received > line.Amount {
+				return &ConflictError{Message: fmt.Sprintf("Product %d can not receive %d units. Ordered %d, Received %d.", line.ProductId, received.Received, line.Amount, line.Received)}
+			}
+
+			catering := new(Catering)
+			catering.ProductId = line.ProductId
+			catering.ProviderId = order.ProviderId
+			catering.HeadquarterId = order.HeadquarterId
+			catering.PurchaseOrderId = order.Id
+			catering.Amount = received.Received
+			catering.UnitCost = line.UnitCost
+			err = cateringDao.create(session, catering, userId)
+			if err != nil {
+				return err
+			}
+
+			line.Received += received.Received
+			_, err = session.ID(line.Id).Cols("received").Update(line)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Resolve the purchase order status.
+		complete := true
+		for _, line := range order.Lines {
+			complete = complete && line.Received == line.Amount
+		}
+		order.Status = PurchaseOrderStatusPartiallyReceived
+		if complete || close {
+			order.Status = PurchaseOrderStatusReceived
+			order.Received = time.Now()
+		}
+		order.ReceivedBy = userId
+		if len(notes) > 0 {
+			order.Notes = notes
+		}
+		_, err = session.ID(order.Id).Cols("status", "received_by", "received", "notes").Update(order)
+
+		return err
+	})
+
+	return order, err
+}
+
+// @Description Cancel the purchase order before it is received.
+// @Param orderId Purchase order Id.
+// @Param userId User cancelling the purchase order.
+func (d *PurchaseOrderDao) Cancel(orderId uint64, userId string) (*PurchaseOrder, error) {
+	var order *PurchaseOrder
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		order, err = d.readForUpdate(session, orderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != PurchaseOrderStatusDraft && order.Status != PurchaseOrderStatusSent {
+			return &ConflictError{Message: fmt.Sprintf("Purchase order %d is %s.", orderId, order.Status)}
+		}
+
+		order.Status = PurchaseOrderStatusCancelled
+		order.CancelledBy = userId
+		order.Cancelled = time.Now()
+		_, err = session.ID(order.Id).Cols("status", "cancelled_by", "cancelled").Update(order)
+
+		return err
+	})
+
+	return order, err
+}
+
+// @Description Validate and insert the purchase order draft with its lines.
+// Lines without unit cost take the product cost.
+// @Param session Transaction session.
+// @Param order Purchase order with its lines.
+func (d *PurchaseOrderDao) create(session *xorm.Session, order *PurchaseOrder) error {
+	// Validate provider.
+	found, err := session.NoCache().ID(order.ProviderId).Exist(new(Provider))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Provider %d does not exist.", order.ProviderId)}
+	}
+
+	// Validate headquarter.
+	found, err = session.NoCache().ID(order.HeadquarterId).Exist(new(Headquarter))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", order.HeadquarterId)}
+	}
+
+	// Validate lines.
+	if len(order.Lines) == 0 {
+		return &ValidationError{Message: "The purchase order must have at least one line."}
+	}
+	products := make(map[uint64]bool)
+	for _, line := range order.Lines {
+		if products[line.ProductId] {
+			return &ValidationError{Message: fmt.Sprintf("Product %d is repeated.", line.ProductId)}
+		}
+		products[line.ProductId] = true
+		if line.Amount == 0 {
+			return &ValidationError{Message: fmt.Sprintf("Product %d must have an amount.", line.ProductId)}
+		}
+		product := new(Product)
+		found, err := session.NoCache().ID(line.ProductId).Get(product)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", line.ProductId)}
+		}
+		if line.UnitCost == 0 {
+			line.UnitCost = product.Cost
+		}
+	}
+
+	// Insert purchase order.
+	order.Status = PurchaseOrderStatusDraft
+	_, err = session.Insert(order)
+	if err != nil {
+		return err
+	}
+
+	// Insert lines.
+	for _, line := range order.Lines {
+		line.Id = 0
+		line.PurchaseOrderId = order.Id
+		line.Received = 0
+		_, err = session.Insert(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @Description Get the ordered amounts not received yet by product.
+// @Param session Session.
+// @Param headquarterId Headquarter Id.
+func (d *PurchaseOrderDao) onOrder(session *xorm.Session, headquarterId uint64) (map[uint64]uint64, error) {
+	lines := make([]*PurchaseOrderLine, 0)
+	err := session.NoCache().Table(PurchaseOrderLineTableName).
+		Join("INNER", PurchaseOrderTableName, "purchase_order.id = purchase_order_line.purchase_order_id").
+		Select("purchase_order_line.product_id, purchase_order_line.amount, purchase_order_line.received").
+		Where("purchase_order.headquarter_id = ?", headquarterId).
+		In("purchase_order.status", PurchaseOrderStatusDraft, PurchaseOrderStatusSent, PurchaseOrderStatusPartiallyReceived).
+		Find(&lines)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[uint64]uint64)
+	for _, line := range lines {
+		amounts[line.ProductId] += line.Amount - line.Received
+	}
+
+	return amounts, nil
+}
+
+// @Description Lock the purchase order row until the transaction ends and
+// get its lines.
+// @Param session Transaction session.
+// @Param orderId Purchase order Id.
+func (d *PurchaseOrderDao) readForUpdate(session *xorm.Session, orderId uint64) (*PurchaseOrder, error) {
+	order := new(PurchaseOrder)
+	found, err := session.NoCache().ForUpdate().ID(orderId).Get(order)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Purchase order %d does not exist.", orderId)}
+	}
+
+	order.Lines, err = d.findLines(session, orderId)
+
+	return order, err
+}
+
+// @Param session Session.
+// @Param orderId Purchase order Id.
+func (d *PurchaseOrderDao) findLines(session *xorm.Session, orderId uint64) ([]*PurchaseOrderLine, error) {
+	lines := make([]*PurchaseOrderLine, 0)
+	err := session.NoCache().Where("purchase_order_id = ?", orderId).Asc("id").Find(&lines)
+
+	return lines, err
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"github.com/go-xorm/xorm"
+	"math"
+	"time"
+)
+
+var (
+	// Days of sales used to compute the average daily sales.
+	ReplenishmentWindowDays int
+
+	// Days of average sales kept as safety stock.
+	ReplenishmentSafetyDays int
+)
+
+// Init replenishment configuration.
+func init() {
+	val, err := beego.AppConfig.Int("replenishment::windowdays")
+	if err != nil {
+		logs.Error(err.Error())
+	}
+	if val <= 0 {
+		val = 30
+	}
+	ReplenishmentWindowDays = val
+
+	val, err = beego.AppConfig.Int("replenishment::safetydays")
+	if err != nil {
+		logs.Error(err.Error())
+		val = 7
+	}
+	if val < 0 {
+		val = 0
+	}
+	ReplenishmentSafetyDays = val
+}
+
+// @Description Suggested order of a headquarter product. The product is
+// ordered up to the target when its stock, in transit and on order amounts
+// fall to the reorder point, which covers the provider lead time sales plus
+// the safety stock and is never below the product minimum level.
+type Suggestion struct {
+	HeadquarterId     uint64  `json:"headquarter_id"`
+	ProductId         uint64  `json:"product_id"`
+	ProviderId        uint64  `json:"provider_id"`
+	AverageDailySales float64 `json:"average_daily_sales"`
+	LeadTimeDays      uint    `json:"lead_time_days"`
+	SafetyStock       uint64  `json:"safety_stock"`
+	ReorderPoint      uint64  `json:"reorder_point"`
+	Target            uint64  `json:"target"`
+	Stock             uint64  `json:"stock"`
+	InTransit         uint64  `json:"in_transit"`
+	OnOrder           uint64  `json:"on_order"`
+	Quantity          uint64  `json:"quantity"`
+	UnitCost          float64 `json:"unit_cost"`
+}
+
+// Units sold of a product.
+type productSales struct {
+	ProductId uint64
+	Amount    uint64
+}
+
+type ReplenishmentDao struct {
+	Dao
+}
+
+func NewReplenishmentDao(schema string) *ReplenishmentDao {
+	d := new(ReplenishmentDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Get the headquarter products to order.
+// @Param headquarterId Headquarter Id.
+// @Param windowDays Days of sales to average, 0 for the configured window.
+func (d *ReplenishmentDao) Suggest(headquarterId uint64, windowDays int) ([]*Suggestion, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	return d.suggest(session, headquarterId, windowDays)
+}
+
+// @Description Convert the suggestions into purchase order drafts, one by
+// provider. The products without provider are not ordered.
+// @Param headquarterId Headquarter Id.
+// @Param providerId Provider Id, 0 for every provider.
+// @Param windowDays Days of sales to average, 0 for the configured window.
+// @Param userId User ordering.
+func (d *ReplenishmentDao) Order(headquarterId, providerId uint64, windowDays int, userId string) ([]*PurchaseOrder, error) {
+	orders := make([]*PurchaseOrder, 0)
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		suggestions, err := d.suggest(session, headquarterId, windowDays)
+		if err != nil {
+			return err
+		}
+
+		// Group the suggestions by provider.
+		byProvider := make(map[uint64]*PurchaseOrder)
+		for _, suggestion := range suggestions {
+			if suggestion.ProviderId == 0 || (providerId > 0 && suggestion.ProviderId != providerId) {
+				continue
+			}
+			order, ok := byProvider[suggestion.ProviderId]
+			if !ok {
+				order = new(PurchaseOrder)
+				order.ProviderId = suggestion.ProviderId
+				order.HeadquarterId = headquarterId
+				order.UserId = userId
+				order.Notes = "Replenishment suggestion."
+				byProvider[suggestion.ProviderId] = order
+				orders = append(orders, order)
+			}
+			line := new(PurchaseOrderLine)
+			line.ProductId = suggestion.ProductId
+			line.Amount = suggestion.Quantity
+			line.UnitCost = suggestion.UnitCost
+			order.Lines = append(order.Lines, line)
+		}
+
+		// Create the purchase orders.
+		purchaseOrderDao := NewPurchaseOrderDao(d.GetSchema())
+		for _, order := range orders {
+			err = purchaseOrderDao.create(session, order)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return orders, err
+}
+
+// @Param session Session.
+// @Param headquarterId Headquarter Id.
+// @Param windowDays Days of sales to average, 0 for the configured window.
+func (d *ReplenishmentDao) suggest(session *xorm.Session, headquarterId uint64, windowDays int) ([]*Suggestion, error) {
+	// Validate headquarter.
+	found, err := session.NoCache().ID(headquarterId).Exist(new(Headquarter))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", headquarterId)}
+	}
+	if windowDays < 0 {
+		return nil, &ValidationError{Message: "The window days can not be negative."}
+	}
+	if windowDays == 0 {
+		windowDays = ReplenishmentWindowDays
+	}
+
+	// Get the units sold in the window, only issued bills are sales.
+	end := time.Now()
+	start := end.AddDate(0, 0, -windowDays)
+	sales := make([]*productSales, 0)
+	err = session.NoCache().Table(SaleTableName).
+		Join("INNER", BillTableName, "bill.id = sale.bill_id").
+		Select("sale.product_id, SUM(sale.amount) AS amount").
+		Where("bill.headquarter_id = ? AND bill.status = ?", headquarterId, BillStatusIssued).
+		And("sale.created >= ? AND sale.created <= ?", start, end).
+		GroupBy("sale.product_id").
+		Find(&sales)
+	if err != nil {
+		return nil, err
+	}
+	sold := make(map[uint64]uint64)
+	for _, sale := range sales {
+		sold[sale.ProductId] = sale.Amount
+	}
+
+	// Get the headquarter products.
+	headquarterProducts := make([]*HeadquarterProduct, 0)
+	err = session.NoCache().Where("headquarter_id = ?", headquarterId).Asc("product_id").Find(&headquarterProducts)
+	if err != nil {
+		return nil, err
+	}
+	if len(headquarterProducts) == 0 {
+		return make([]*Suggestion, 0), nil
+	}
+	ids := make([]uint64, 0, len(headquarterProducts))
+	for _, headquarterProduct := range headquarterProducts {
+		ids = append(ids, headquarterProduct.ProductId)
+	}
+
+	// Get the products.
+	products := make([]*Product, 0)
+	err = session.NoCache().In("id", ids).Find(&products)
+	if err != nil {
+		return nil, err
+	}
+	productsById := make(map[uint64]*Product)
+	for _, product := range products {
+		productsById[product.Id] = product
+	}
+
+	// The products without provider take the provider of their last catering.
+	caterings := make([]*Catering, 0)
+	err = session.NoCache().In("product_id", ids).Desc("id").Find(&caterings)
+	if err != nil {
+		return nil, err
+	}
+	lastProvider := make(map[uint64]uint64)
+	for _, catering := range caterings {
+		if _, ok := lastProvider[catering.ProductId]; !ok {
+			lastProvider[catering.ProductId] = catering.ProviderId
+		}
+	}
+
+	// Get the provider lead times.
+	providers := make([]*Provider, 0)
+	err = session.NoCache().Find(&providers)
+	if err != nil {
+		return nil, err
+	}
+	leadTimes := make(map[uint64]uint)
+	for _, provider := range providers {
+		leadTimes[provider.Id] = provider.LeadTimeDays
+	}
+
+	// Get the stock in transit and on order.
+	transit, err := NewTransferDao(d.GetSchema()).InTransit(headquarterId)
+	if err != nil {
+		return nil, err
+	}
+	inTransit := make(map[uint64]uint64)
+	for _, stock := range transit {
+		inTransit[stock.ProductId] += stock.Amount
+	}
+	onOrder, err := NewPurchaseOrderDao(d.GetSchema()).onOrder(session, headquarterId)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]*Suggestion, 0)
+	for _, headquarterProduct := range headquarterProducts {
+		product, ok := productsById[headquarterProduct.ProductId]
+		if !ok {
+			continue
+		}
+
+		suggestion := new(Suggestion)
+		suggestion.HeadquarterId = headquarterId
+		suggestion.ProductId = product.Id
+		suggestion.ProviderId = product.ProviderId
+		if suggestion.ProviderId == 0 {
+			suggestion.ProviderId = lastProvider[product.Id]
+		}
+		suggestion.UnitCost = product.Cost
+		suggestion.AverageDailySales = float64(sold[product.Id]) / float64(windowDays)
+		suggestion.LeadTimeDays = leadTimes[suggestion.ProviderId]
+		suggestion.SafetyStock = uint64(math.Ceil(suggestion.AverageDailySales * float64(ReplenishmentSafetyDays)))
+		suggestion.ReorderPoint = uint64(math.Ceil(suggestion.AverageDailySales*float64(suggestion.LeadTimeDays))) + suggestion.SafetyStock
+		if suggestion.ReorderPoint < headquarterProduct.Minimum {
+			suggestion.ReorderPoint = headquarterProduct.Minimum
+		}
+		suggestion.Target = suggestion.ReorderPoint
+		if suggestion.Target < headquarterProduct.Maximum {
+			suggestion.Target = headquarterProduct.Maximum
+		}
+		suggestion.Stock = headquarterProduct.Amount
+		suggestion.InTransit = inTransit[product.Id]
+		suggestion.OnOrder = onOrder[product.Id]
+
+		// Order up to the target when the position falls to the reorder point.
+		position := suggestion.Stock + suggestion.InTransit + suggestion.OnOrder
+		if suggestion.ReorderPoint == 0 || position > suggestion.ReorderPoint || position >= suggestion.Target {
+			continue
+		}
+		suggestion.Quantity = suggestion.Target - position
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
@@ -4,6 +4,7 @@ import (
 	"app-rest-inventory/util/stringutil"
 	"bytes"
 	"fmt"
+	"github.com/go-xorm/xorm"
 	"time"
 )
 
@@ -13,18 +14,85 @@ var (
 
 // @Description Sale or bill item.
 type Sale struct {
-	Id        uint64    `xorm:"pk autoincr" json:"id"`
-	BillId    uint64    `xorm:"index" json:"bill_id"`
-	ProductId uint64    `xorm:"index" json:"product_id"`
-	Amount    uint64    `xorm:"not null" json:"amount"`
-	Created   time.Time `xorm:"created" json:"created"`
-	Updated   time.Time `xorm:"updated" json:"updated"`
+	Id                uint64    `xorm:"pk autoincr" json:"id"`
+	BillId            uint64    `xorm:"index" json:"bill_id"`
+	ProductId         uint64    `xorm:"index" json:"product_id"`
+	Amount            uint64    `xorm:"not null" json:"amount"`
+	UnitPrice         float64   `json:"unit_price"`
+	UnitCost          float64   `json:"unit_cost"`
+	Discount          float64   `xorm:"not null default 0" json:"discount"`
+	DiscountType      string    `xorm:"not null default 'fixed'" json:"discount_type"`
+	DiscountRate      float64   `xorm:"not null default 0" json:"discount_rate"`
+	PromotionId       uint64    `xorm:"not null default 0" json:"promotion_id"`
+	PromotionDiscount float64   `xorm:"not null default 0" json:"promotion_discount"`
+	TaxRate           float64   `xorm:"not null default 0" json:"tax_rate"`
+	TaxIncluded       bool      `xorm:"not null default false" json:"tax_included"`
+	Created           time.Time `xorm:"created" json:"created"`
+	Updated           time.Time `xorm:"updated" json:"updated"`
 }
 
 func (s *Sale) TableName() string {
 	return SaleTableName
 }
 
+// @Description Sale line amount at the snapshot price after the promotion.
+func (s *Sale) promoted() float64 {
+	return float64(s.Amount)*s.UnitPrice - s.PromotionDiscount
+}
+
+// @Description Sale line amount at the snapshot price after the promotion
+// and the discount.
+func (s *Sale) gross() float64 {
+	return s.promoted() - s.Discount
+}
+
+// @Description Sale line amount with taxes before the discount, promotions
+// are not discounts given by the seller.
+func (s *Sale) listTotal() float64 {
+	list := *s
+	list.Discount = 0
+	return list.Total()
+}
+
+// @Description Resolve the line discount from the snapshot price after the
+// promotion.
+func (s *Sale) applyDiscount() error {
+	if len(s.DiscountType) == 0 {
+		s.DiscountType = DiscountFixed
+	}
+
+	var err error
+	s.Discount, err = discountAmount(s.DiscountType, s.DiscountRate, s.Discount, s.promoted())
+
+	return err
+}
+
+// @Description Sale line amount without taxes.
+func (s *Sale) Subtotal() float64 {
+	if s.TaxIncluded {
+		return s.gross() / (1 + s.TaxRate/100)
+	}
+	return s.gross()
+}
+
+// @Description Sale line taxes.
+func (s *Sale) Tax() float64 {
+	if s.TaxIncluded {
+		return s.gross() - s.Subtotal()
+	}
+	return s.gross() * s.TaxRate / 100
+}
+
+// @Description Sale line amount with taxes.
+func (s *Sale) Total() float64 {
+	return s.Subtotal() + s.Tax()
+}
+
+// @Description Sale line cost at the snapshot cost.
+func (s *Sale) Cost() float64 {
+	return float64(s.Amount) * s.UnitCost
+}
+
 // In order to access the product's sales in a bill we need to
 // do a join between bill, sale and product tables in the xorm way.
 type SaleBillProduct struct {
@@ -33,6 +101,31 @@ type SaleBillProduct struct {
 	Product `xorm:"extends"`
 }
 
+// @Description Sales created before the price snapshots take the current
+// product price and cost.
+// @Param session Migration session.
+// @Param schema Customer schema.
+func migrateSaleSnapshots(session *xorm.Session, schema string) error {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("UPDATE ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(SaleTableName)
+	sql.WriteString(" s SET unit_price = p.price, unit_cost = p.cost FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(schema)
+	sql.WriteString("\".")
+	sql.WriteString(ProductTableName)
+	sql.WriteString(" p WHERE s.product_id = p.id AND (s.unit_price IS NULL OR s.unit_cost IS NULL)")
+
+	// Execute sentence.
+	_, err := session.Exec(sql.String())
+
+	return err
+}
+
 type SaleDao struct {
 	Dao
 }
@@ -44,9 +137,8 @@ func NewSaleDao(schema string) *SaleDao {
 	return d
 }
 
-// FindByBill
 // @Param BillId Bill Id.
-func (d *SaleDao) FindByBill(billId uint64) ([]*SaleBillProduct, error) {
+func (d *SaleDao) findByBillSql(billId uint64) string {
 	// Build sentence.
 	var sql bytes.Buffer
 	sql.WriteString("SELECT * FROM ")
@@ -65,17 +157,38 @@ func (d *SaleDao) FindByBill(billId uint64) ([]*SaleBillProduct, error) {
 	sql.WriteString(d.GetSchema())
 	sql.WriteString("\".")
 	sql.WriteString(ProductTableName)
-	sql.WriteString(" b ON s.product_id = p.id ")
+	sql.WriteString(" p ON s.product_id = p.id ")
 	sql.WriteString("WHERE s.bill_id = ")
 	sql.WriteString(fmt.Sprintf("%v", billId))
 	sql.WriteString(" ORDER BY s.id ASC")
 
+	return sql.String()
+}
+
+// FindByBill
+// @Param BillId Bill Id.
+func (d *SaleDao) FindByBill(billId uint64) ([]*SaleBillProduct, error) {
 	// Get engine.
 	engine := GetEngine(d.GetSchema())
 	sales := make([]*SaleBillProduct, 0)
 
 	// Execute sentence.
-	err := engine.Sql(sql.String()).AllCols().Find(&sales)
+	err := engine.Sql(d.findByBillSql(billId)).AllCols().Find(&sales)
+	if err != nil {
+		return nil, err
+	}
+
+	return sales, err
+}
+
+// @Description Get the bill sales inside a transaction.
+// @Param session Transaction session.
+// @Param BillId Bill Id.
+func (d *SaleDao) FindByBillInSession(session *xorm.Session, billId uint64) ([]*SaleBillProduct, error) {
+	sales := make([]*SaleBillProduct, 0)
+
+	// Execute sentence.
+	err := session.Sql(d.findByBillSql(billId)).AllCols().Find(&sales)
 	if err != nil {
 		return nil, err
 	}
@@ -214,40 +327,61 @@ func (d *SaleDao) FindByHeadquarterIDAndDates(headquarterID uint64, start, end t
 	return sales, err
 }
 
+// @Description Get the purchase history of a buyer.
+// @Param buyerID Buyer ID.
+func (d *SaleDao) FindByBuyerID(buyerID uint64) ([]*SaleBillProduct, error) {
+	// Build sentence.
+	var sql bytes.Buffer
+	sql.WriteString("SELECT * FROM ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(SaleTableName)
+	sql.WriteString(" s INNER JOIN ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(BillTableName)
+	sql.WriteString(" b ON s.bill_id = b.id AND b.buyer_id = ")
+	sql.WriteString(fmt.Sprintf("%v", buyerID))
+	sql.WriteString(" INNER JOIN ")
+	sql.WriteString("\"")
+	sql.WriteString(d.GetSchema())
+	sql.WriteString("\".")
+	sql.WriteString(ProductTableName)
+	sql.WriteString(" p ON s.product_id = p.id ")
+	sql.WriteString("ORDER BY b.id DESC")
+
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+	sales := make([]*SaleBillProduct, 0)
+
+	// Execute sentence.
+	err := engine.Sql(sql.String()).AllCols().Find(&sales)
+	if err != nil {
+		return nil, err
+	}
+
+	return sales, err
+}
+
 // @Description Get revenue by dates.
 // @Param start Start time.
 // @Param end End time.
 func (d *SaleDao) RevenueByDates(start, end time.Time) (float64, error) {
-	var revenue float64
-
 	// Find by dates and then group by bill programatically.
 	sales, err := d.FindByDates(start, end)
 	if err != nil {
-		return revenue, err
+		return 0, err
 	}
-	// Group by bill.
-	bills := make(map[uint64][]*SaleBillProduct)
-	for _, sale := range sales {
-		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], sale)
-	}
-
-	// Calculate revenue.
-	for _, bSales := range bills {
-		// Calculate bill revenue.
-		var billRevenue float64
-		for _, sale := range bSales {
-			billRevenue += float64(sale.Sale.Amount) * sale.Product.Price
-		}
-		// Apply discount.
-		if len(bSales) > 0 {
-			billRevenue -= bSales[0].Bill.Discount
-		}
 
-		// Add bill revenue.
-		revenue += billRevenue
+	// Get the returns.
+	lines, err := NewCreditNoteDao(d.GetSchema()).FindLinesByDates(start, end)
+	if err != nil {
+		return 0, err
 	}
 
-	return revenue, nil
+	return revenueOf(sales) - refundOf(lines), nil
 }
 
 // @Description Get revenue by headquarter ID and dates.
@@ -255,53 +389,199 @@ func (d *SaleDao) RevenueByDates(start, end time.Time) (float64, error) {
 // @Param start Start time.
 // @Param end End time.
 func (d *SaleDao) RevenueByHeadquarterIDAndDates(headquarterID uint64, start, end time.Time) (float64, error) {
-	var revenue float64
+	// Find by dates and then group by bill programatically.
+	sales, err := d.FindByHeadquarterIDAndDates(headquarterID, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	// Get the returns.
+	lines, err := NewCreditNoteDao(d.GetSchema()).FindLinesByHeadquarterIDAndDates(headquarterID, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	return revenueOf(sales) - refundOf(lines), nil
+}
+
+// @Description Get taxes by dates.
+// @Param start Start time.
+// @Param end End time.
+func (d *SaleDao) TaxByDates(start, end time.Time) (float64, error) {
+	// Find by dates and then group by bill programatically.
+	sales, err := d.FindByDates(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	// Get the returns.
+	lines, err := NewCreditNoteDao(d.GetSchema()).FindLinesByDates(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	return taxOf(sales) - refundedTaxOf(lines), nil
+}
+
+// @Description Get taxes by headquarter ID and dates.
+// @Param headquarterID Headquarter ID.
+// @Param start Start time.
+// @Param end End time.
+func (d *SaleDao) TaxByHeadquarterIDAndDates(headquarterID uint64, start, end time.Time) (float64, error) {
+	// Find by dates and then group by bill programatically.
+	sales, err := d.FindByHeadquarterIDAndDates(headquarterID, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	// Get the returns.
+	lines, err := NewCreditNoteDao(d.GetSchema()).FindLinesByHeadquarterIDAndDates(headquarterID, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	return taxOf(sales) - refundedTaxOf(lines), nil
+}
+
+// @Description Get margin by dates.
+// @Param start Start time.
+// @Param end End time.
+func (d *SaleDao) MarginByDates(start, end time.Time) (float64, error) {
+	// Find by dates and then group by bill programatically.
+	sales, err := d.FindByDates(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	// Get the returns.
+	lines, err := NewCreditNoteDao(d.GetSchema()).FindLinesByDates(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	return marginOf(sales, lines), nil
+}
 
+// @Description Get margin by headquarter ID and dates.
+// @Param headquarterID Headquarter ID.
+// @Param start Start time.
+// @Param end End time.
+func (d *SaleDao) MarginByHeadquarterIDAndDates(headquarterID uint64, start, end time.Time) (float64, error) {
 	// Find by dates and then group by bill programatically.
 	sales, err := d.FindByHeadquarterIDAndDates(headquarterID, start, end)
 	if err != nil {
-		return revenue, err
+		return 0, err
+	}
+
+	// Get the returns.
+	lines, err := NewCreditNoteDao(d.GetSchema()).FindLinesByHeadquarterIDAndDates(headquarterID, start, end)
+	if err != nil {
+		return 0, err
 	}
+
+	return marginOf(sales, lines), nil
+}
+
+// @Description Calculate the subtotal, taxes and total of the sales grouped by
+// bill. Only issued bills are taken into account.
+// @Param sales Sales.
+func amountsOf(sales []*SaleBillProduct) (subtotal, tax, total float64) {
 	// Group by bill.
-	bills := make(map[uint64][]*SaleBillProduct)
+	bills := make(map[uint64][]*Sale)
+	discounts := make(map[uint64]float64)
 	for _, sale := range sales {
-		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], sale)
+		if sale.Bill.Status != BillStatusIssued {
+			continue
+		}
+		bills[sale.Sale.BillId] = append(bills[sale.Sale.BillId], &sale.Sale)
+		discounts[sale.Sale.BillId] = sale.Bill.totalDiscount()
 	}
 
-	// Calculate revenue.
-	for _, bSales := range bills {
-		// Calculate bill revenue.
-		var billRevenue float64
-		for _, sale := range bSales {
-			billRevenue += float64(sale.Sale.Amount) * sale.Product.Price
-		}
-		// Apply discount.
-		if len(bSales) > 0 {
-			billRevenue -= bSales[0].Bill.Discount
+	// Add the bill amounts.
+	for billId, bSales := range bills {
+		billSubtotal, billTax, billTotal := BillAmounts(bSales, discounts[billId])
+		subtotal += billSubtotal
+		tax += billTax
+		total += billTotal
+	}
+
+	return subtotal, tax, total
+}
+
+// @Description Calculate the revenue of the sales grouped by bill. Only
+// issued bills are taken into account.
+// @Param sales Sales.
+func revenueOf(sales []*SaleBillProduct) float64 {
+	_, _, total := amountsOf(sales)
+	return total
+}
+
+// @Description Calculate the taxes of the sales grouped by bill. Only issued
+// bills are taken into account.
+// @Param sales Sales.
+func taxOf(sales []*SaleBillProduct) float64 {
+	_, tax, _ := amountsOf(sales)
+	return tax
+}
+
+// @Description Calculate the cost of the sales. Only issued bills are taken
+// into account.
+// @Param sales Sales.
+func costOf(sales []*SaleBillProduct) float64 {
+	var cost float64
+	for _, sale := range sales {
+		if sale.Bill.Status != BillStatusIssued {
+			continue
 		}
+		cost += sale.Sale.Cost()
+	}
+
+	return cost
+}
 
-		// Add bill revenue.
-		revenue += billRevenue
+// @Description Calculate the refunded amount of the returned lines. Returns of
+// voided bills are not taken into account.
+// @Param lines Returned lines.
+func refundOf(lines []*CreditNoteLineCreditNoteBill) float64 {
+	var refund float64
+	for _, line := range lines {
+		if line.Bill.Status == BillStatusVoided {
+			continue
+		}
+		refund += line.CreditNoteLine.Total
 	}
 
-	return revenue, nil
+	return refund
 }
 
-// @param billID Bill ID.
-func (d *SaleDao) DeleteByBillId(billID uint64) error {
-	// Build sentence.
-	var sql bytes.Buffer
-	sql.WriteString("DELETE FROM ")
-	sql.WriteString("\"")
-	sql.WriteString(d.GetSchema())
-	sql.WriteString("\".")
-	sql.WriteString(SaleTableName)
-	sql.WriteString(" WHERE s.bill_id = ")
-	sql.WriteString(fmt.Sprintf("%v", billID))
+// @Description Calculate the refunded taxes of the returned lines. Returns of
+// voided bills are not taken into account.
+// @Param lines Returned lines.
+func refundedTaxOf(lines []*CreditNoteLineCreditNoteBill) float64 {
+	var tax float64
+	for _, line := range lines {
+		if line.Bill.Status == BillStatusVoided {
+			continue
+		}
+		tax += line.CreditNoteLine.Tax
+	}
 
-	// Get engine.
-	engine := GetEngine(d.GetSchema())
-	_, err := engine.Exec(sql.String())
+	return tax
+}
 
-	return err
+// @Description Calculate the net margin, without taxes, of the sales and
+// their returns.
+// @Param sales Sales.
+// @Param lines Returned lines.
+func marginOf(sales []*SaleBillProduct, lines []*CreditNoteLineCreditNoteBill) float64 {
+	subtotal, _, _ := amountsOf(sales)
+	margin := subtotal - costOf(sales)
+	for _, line := range lines {
+		if line.Bill.Status == BillStatusVoided {
+			continue
+		}
+		margin -= line.CreditNoteLine.Total - line.CreditNoteLine.Tax - line.CreditNoteLine.Cost
+	}
+
+	return margin
 }
@@ -0,0 +1,331 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	ShiftTableName        = "shift"
+	CashMovementTableName = "cash_movement"
+)
+
+// Shift status.
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+)
+
+// Cash movement types, both take cash out of the register.
+const (
+	CashMovementDrop   = "drop"
+	CashMovementPayout = "payout"
+)
+
+// @Description Cash register session of a user in a headquarter.
+type Shift struct {
+	Id            uint64    `xorm:"pk autoincr" json:"id"`
+	HeadquarterId uint64    `xorm:"index" json:"headquarter_id"`
+	UserId        string    `xorm:"index" json:"user_id"`
+	Status        string    `xorm:"index not null" json:"status"`
+	OpeningFloat  float64   `xorm:"not null default 0" json:"opening_float"`
+	ExpectedCash  float64   `xorm:"not null default 0" json:"expected_cash"`
+	CountedCash   float64   `xorm:"not null default 0" json:"counted_cash"`
+	Variance      float64   `xorm:"not null default 0" json:"variance"`
+	Notes         string    `json:"notes"`
+	Closed        time.Time `xorm:"null" json:"closed"`
+	Created       time.Time `xorm:"created" json:"created"`
+	Updated       time.Time `xorm:"updated" json:"updated"`
+}
+
+func (s *Shift) TableName() string {
+	return ShiftTableName
+}
+
+// @Description Cash taken out of the register during a shift.
+type CashMovement struct {
+	Id      uint64    `xorm:"pk autoincr" json:"id"`
+	ShiftId uint64    `xorm:"index" json:"shift_id"`
+	UserId  string    `xorm:"index" json:"user_id"`
+	Type    string    `xorm:"not null" json:"type"`
+	Amount  float64   `xorm:"not null default 0" json:"amount"`
+	Reason  string    `json:"reason"`
+	Created time.Time `xorm:"created" json:"created"`
+	Updated time.Time `xorm:"updated" json:"updated"`
+}
+
+func (c *CashMovement) TableName() string {
+	return CashMovementTableName
+}
+
+// @Description Expected vs counted cash of a shift.
+type ShiftReport struct {
+	Shift        *Shift          `json:"shift"`
+	Bills        int             `json:"bills"`
+	CashSales    float64         `json:"cash_sales"`
+	CashRefunds  float64         `json:"cash_refunds"`
+	Drops        float64         `json:"drops"`
+	Payouts      float64         `json:"payouts"`
+	ExpectedCash float64         `json:"expected_cash"`
+	CountedCash  float64         `json:"counted_cash"`
+	Variance     float64         `json:"variance"`
+	Movements    []*CashMovement `json:"movements"`
+}
+
+type ShiftDao struct {
+	Dao
+}
+
+func NewShiftDao(schema string) *ShiftDao {
+	d := new(ShiftDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Open a shift. A user can only have one open shift per
+// headquarter.
+// @Param shift Shift.
+func (d *ShiftDao) Open(shift *Shift) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		// Lock the headquarter to serialize the shifts opening.
+		headquarter := new(Headquarter)
+		found, err := session.NoCache().ForUpdate().ID(shift.HeadquarterId).Get(headquarter)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", shift.HeadquarterId)}
+		}
+
+		// Validate there is no open shift.
+		current, err := d.FindOpen(session, shift.HeadquarterId, shift.UserId)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			return &ConflictError{Message: fmt.Sprintf("User %s already has the shift %d open.", shift.UserId, current.Id)}
+		}
+
+		// Insert shift.
+		shift.Status = ShiftStatusOpen
+		_, err = session.Insert(shift)
+
+		return err
+	})
+}
+
+// @Description Get the open shift of the user in the headquarter, nil if
+// there is none. The shift row is locked until the transaction ends.
+// @Param session Transaction session.
+// @Param headquarterId Headquarter Id.
+// @Param userId User Id.
+func (d *ShiftDao) FindOpen(session *xorm.Session, headquarterId uint64, userId string) (*Shift, error) {
+	shift := new(Shift)
+	found, err := session.NoCache().ForUpdate().
+		Where("headquarter_id = ? AND user_id = ? AND status = ?", headquarterId, userId, ShiftStatusOpen).
+		Get(shift)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return shift, nil
+}
+
+// @Description Lock the open shift row until the transaction ends.
+// @Param session Transaction session.
+// @Param shiftId Shift Id.
+func (d *ShiftDao) readOpenForUpdate(session *xorm.Session, shiftId uint64) (*Shift, error) {
+	shift := new(Shift)
+	found, err := session.NoCache().ForUpdate().ID(shiftId).Get(shift)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Shift %d does not exist.", shiftId)}
+	}
+	if shift.Status != ShiftStatusOpen {
+		return nil, &ConflictError{Message: fmt.Sprintf("Shift %d is closed.", shiftId)}
+	}
+
+	return shift, nil
+}
+
+// @Description Register a cash drop or payout in an open shift.
+// @Param movement Cash movement.
+func (d *ShiftDao) AddMovement(movement *CashMovement) error {
+	// Validate movement.
+	if movement.Type != CashMovementDrop && movement.Type != CashMovementPayout {
+		return &ValidationError{Message: fmt.Sprintf("Cash movement type %s is not supported.", movement.Type)}
+	}
+	if movement.Amount <= 0 {
+		return &ValidationError{Message: "Cash movement amount must be positive."}
+	}
+
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		_, err := d.readOpenForUpdate(session, movement.ShiftId)
+		if err != nil {
+			return err
+		}
+
+		_, err = session.Insert(movement)
+
+		return err
+	})
+}
+
+// @Description Close the shift with the counted cash storing the expected
+// cash and the variance.
+// @Param shiftId Shift Id.
+// @Param counted Counted cash.
+// @Param notes Closing notes.
+func (d *ShiftDao) Close(shiftId uint64, counted float64, notes string) (*ShiftReport, error) {
+	var report *ShiftReport
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		shift, err := d.readOpenForUpdate(session, shiftId)
+		if err != nil {
+			return err
+		}
+
+		// Calculate the expected cash.
+		report, err = d.report(session, shift)
+		if err != nil {
+			return err
+		}
+
+		// Close shift.
+		shift.Status = ShiftStatusClosed
+		shift.ExpectedCash = report.ExpectedCash
+		shift.CountedCash = counted
+		shift.Variance = counted - report.ExpectedCash
+		shift.Notes = notes
+		shift.Closed = time.Now()
+		_, err = session.ID(shift.Id).Cols("status", "expected_cash", "counted_cash", "variance", "notes", "closed").Update(shift)
+		if err != nil {
+			return err
+		}
+
+		report.CountedCash = shift.CountedCash
+		report.Variance = shift.Variance
+
+		return nil
+	})
+
+	return report, err
+}
+
+// @Description Get the shift report. Closed shifts keep the expected cash
+// calculated when they were closed.
+// @Param shiftId Shift Id.
+func (d *ShiftDao) Report(shiftId uint64) (*ShiftReport, error) {
+	var report *ShiftReport
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		shift := new(Shift)
+		found, err := session.NoCache().ID(shiftId).Get(shift)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &NotFoundError{Message: fmt.Sprintf("Shift %d does not exist.", shiftId)}
+		}
+
+		report, err = d.report(session, shift)
+		if err != nil {
+			return err
+		}
+
+		// Closed shifts are reported as they were closed.
+		if shift.Status == ShiftStatusClosed {
+			report.ExpectedCash = shift.ExpectedCash
+			report.CountedCash = shift.CountedCash
+			report.Variance = shift.Variance
+		}
+
+		return nil
+	})
+
+	return report, err
+}
+
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+// @Param status Shift status, empty for every status.
+func (d *ShiftDao) FindByHeadquarterAndStatus(headquarterId uint64, status string) ([]*Shift, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Desc("id")
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+	if len(status) > 0 {
+		session = session.And("status = ?", status)
+	}
+
+	shifts := make([]*Shift, 0)
+	err := session.Find(&shifts)
+
+	return shifts, err
+}
+
+// @Description Calculate the shift cash: opening float plus cash sales of
+// the issued bills minus cash refunds, drops and payouts.
+// @Param session Transaction session.
+// @Param shift Shift.
+func (d *ShiftDao) report(session *xorm.Session, shift *Shift) (*ShiftReport, error) {
+	report := new(ShiftReport)
+	report.Shift = shift
+
+	// Get the shift bills.
+	bills := make([]*Bill, 0)
+	err := session.NoCache().Where("shift_id = ? AND status <> ?", shift.Id, BillStatusVoided).Find(&bills)
+	if err != nil {
+		return nil, err
+	}
+	report.Bills = len(bills)
+
+	// Get the cash payments.
+	if len(bills) > 0 {
+		ids := make([]uint64, 0)
+		for _, bill := range bills {
+			ids = append(ids, bill.Id)
+		}
+		payments := make([]*Payment, 0)
+		err = session.NoCache().In("bill_id", ids).And("method = ?", PaymentMethodCash).Find(&payments)
+		if err != nil {
+			return nil, err
+		}
+		for _, payment := range payments {
+			report.CashSales += payment.Amount
+		}
+	}
+
+	// Get the cash refunds.
+	report.CashRefunds, err = session.NoCache().Where("shift_id = ? AND refund_method = ?", shift.Id, PaymentMethodCash).Sum(new(CreditNote), "total")
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the cash movements.
+	report.Movements = make([]*CashMovement, 0)
+	err = session.NoCache().Where("shift_id = ?", shift.Id).Asc("id").Find(&report.Movements)
+	if err != nil {
+		return nil, err
+	}
+	for _, movement := range report.Movements {
+		switch movement.Type {
+		case CashMovementDrop:
+			report.Drops += movement.Amount
+		case CashMovementPayout:
+			report.Payouts += movement.Amount
+		}
+	}
+
+	report.ExpectedCash = shift.OpeningFloat + report.CashSales - report.CashRefunds - report.Drops - report.Payouts
+
+	return report, nil
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"app-rest-inventory/util/notifier"
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"github.com/go-xorm/xorm"
+	"strings"
+	"time"
+)
+
+var (
+	StockAlertTableName = "stock_alert"
+
+	// Delivers the stock alerts.
+	AlertNotifier notifier.Notifier
+)
+
+// Init alerts configuration.
+func init() {
+	switch beego.AppConfig.String("alerts::notifier") {
+	case "webhook":
+		AlertNotifier = notifier.NewWebhook(beego.AppConfig.String("alerts::webhookurl"))
+	case "email":
+		to := make([]string, 0)
+		for _, address := range strings.Split(beego.AppConfig.String("alerts::smtpto"), ",") {
+			if address = strings.TrimSpace(address); len(address) > 0 {
+				to = append(to, address)
+			}
+		}
+		AlertNotifier = notifier.NewSMTP(beego.AppConfig.String("alerts::smtpaddr"), beego.AppConfig.String("alerts::smtpfrom"), to)
+	default:
+		AlertNotifier = notifier.NewLog()
+	}
+}
+
+// @Description Headquarter product stock that fell to its minimum level.
+type StockAlert struct {
+	Id            uint64    `xorm:"pk autoincr" json:"id"`
+	HeadquarterId uint64    `xorm:"index" json:"headquarter_id"`
+	ProductId     uint64    `xorm:"index" json:"product_id"`
+	Amount        uint64    `xorm:"not null" json:"amount"`
+	Minimum       uint64    `xorm:"not null" json:"minimum"`
+	Maximum       uint64    `xorm:"not null default 0" json:"maximum"`
+	Reason        string    `xorm:"not null" json:"reason"`
+	ReferenceId   uint64    `xorm:"not null default 0" json:"reference_id"`
+	Notified      bool      `xorm:"index not null default false" json:"notified"`
+	Created       time.Time `xorm:"created" json:"created"`
+}
+
+func (s *StockAlert) TableName() string {
+	return StockAlertTableName
+}
+
+// @Description Build the alert notification.
+func (s *StockAlert) Notification() *notifier.Notification {
+	return &notifier.Notification{
+		Subject: fmt.Sprintf("Low stock of product %d in headquarter %d.", s.ProductId, s.HeadquarterId),
+		Body:    fmt.Sprintf("Product %d has %d units in headquarter %d, its minimum is %d.", s.ProductId, s.Amount, s.HeadquarterId, s.Minimum),
+		Data:    s,
+	}
+}
+
+type StockAlertDao struct {
+	Dao
+}
+
+func NewStockAlertDao(schema string) *StockAlertDao {
+	d := new(StockAlertDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Find the stock alerts, the latest first.
+// @Param headquarterId Headquarter Id, 0 for every headquarter.
+// @Param start Start date, zero for no start.
+// @Param end End date, zero for no end.
+func (d *StockAlertDao) FindByHeadquarter(headquarterId uint64, start, end time.Time) ([]*StockAlert, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Desc("id")
+	if headquarterId > 0 {
+		session = session.And("headquarter_id = ?", headquarterId)
+	}
+	if !start.IsZero() {
+		session = session.And("created >= ?", start)
+	}
+	if !end.IsZero() {
+		session = session.And("created <= ?", end)
+	}
+
+	alerts := make([]*StockAlert, 0)
+	err := session.Find(&alerts)
+
+	return alerts, err
+}
+
+// @Description Raise an alert when the stock change crosses the headquarter
+// product minimum level. The alert is stored with the transaction so only
+// committed changes are delivered by NotifyStockAlerts.
+// @Param session Transaction session.
+// @Param headquarterProduct Headquarter product with the resulting amount.
+// @Param previous Amount before the change.
+// @Param reason Stock movement reason.
+// @Param referenceId Id of the document changing the stock.
+func (d *StockAlertDao) check(session *xorm.Session, headquarterProduct *HeadquarterProduct, previous uint64, reason string, referenceId uint64) error {
+	if headquarterProduct.Minimum == 0 || previous <= headquarterProduct.Minimum || headquarterProduct.Amount > headquarterProduct.Minimum {
+		return nil
+	}
+
+	alert := new(StockAlert)
+	alert.HeadquarterId = headquarterProduct.HeadquarterId
+	alert.ProductId = headquarterProduct.ProductId
+	alert.Amount = headquarterProduct.Amount
+	alert.Minimum = headquarterProduct.Minimum
+	alert.Maximum = headquarterProduct.Maximum
+	alert.Reason = reason
+	alert.ReferenceId = referenceId
+	_, err := session.Insert(alert)
+
+	return err
+}
+
+// @Description Deliver the pending alerts, the failed ones are retried on the
+// next call.
+func (d *StockAlertDao) notify() error {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	alerts := make([]*StockAlert, 0)
+	err := engine.Where("notified = ?", false).Asc("id").Find(&alerts)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		err = AlertNotifier.Notify(alert.Notification())
+		if err != nil {
+			return err
+		}
+
+		alert.Notified = true
+		_, err = engine.ID(alert.Id).Cols("notified").Update(alert)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @Description Deliver the pending stock alerts of every customer each
+// interval.
+// @Param interval Time between deliveries.
+func NotifyStockAlerts(interval time.Duration) {
+	for range time.Tick(interval) {
+		for customerID := range pool.Items() {
+			err := NewStockAlertDao(customerID).notify()
+			if err != nil {
+				logs.Error(err.Error())
+			}
+		}
+	}
+}
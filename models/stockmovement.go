@@ -0,0 +1,99 @@
+package models
+
+import (
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	StockMovementTableName = "stock_movement"
+)
+
+// Stock movement reasons.
+const (
+	StockReasonSale       = "sale"
+	StockReasonVoid       = "void"
+	StockReasonReturn     = "return"
+	StockReasonCatering   = "catering"
+	StockReasonTransfer   = "transfer"
+	StockReasonAdjustment = "adjustment"
+)
+
+// @Description Append only record of a headquarter product stock change.
+type StockMovement struct {
+	Id            uint64    `xorm:"pk autoincr" json:"id"`
+	HeadquarterId uint64    `xorm:"index(stock_movement_product)" json:"headquarter_id"`
+	ProductId     uint64    `xorm:"index(stock_movement_product)" json:"product_id"`
+	Delta         int64     `xorm:"not null" json:"delta"`
+	Balance       uint64    `xorm:"not null" json:"balance"`
+	Reason        string    `xorm:"index not null" json:"reason"`
+	ReferenceId   uint64    `xorm:"not null default 0" json:"reference_id"`
+	UserId        string    `json:"user_id"`
+	Created       time.Time `xorm:"created" json:"created"`
+}
+
+func (s *StockMovement) TableName() string {
+	return StockMovementTableName
+}
+
+type StockMovementDao struct {
+	Dao
+}
+
+func NewStockMovementDao(schema string) *StockMovementDao {
+	d := new(StockMovementDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Find the stock movements of the headquarter product, the
+// latest first.
+// @Param headquarterId Headquarter Id.
+// @Param productId Product Id.
+// @Param start Start date, zero for no start.
+// @Param end End date, zero for no end.
+func (d *StockMovementDao) FindByHeadquarterAndProduct(headquarterId, productId uint64, start, end time.Time) ([]*StockMovement, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Where("headquarter_id = ? AND product_id = ?", headquarterId, productId)
+	if !start.IsZero() {
+		session = session.And("created >= ?", start)
+	}
+	if !end.IsZero() {
+		session = session.And("created <= ?", end)
+	}
+
+	movements := make([]*StockMovement, 0)
+	err := session.Desc("id").Find(&movements)
+
+	return movements, err
+}
+
+// @Description Record the stock change of the headquarter product, it must
+// already have the resulting amount.
+// @Param session Transaction session.
+// @Param headquarterProduct Headquarter product.
+// @Param delta Stock change.
+// @Param reason Stock movement reason.
+// @Param referenceId Id of the document changing the stock.
+// @Param userId User changing the stock.
+func (d *StockMovementDao) record(session *xorm.Session, headquarterProduct *HeadquarterProduct, delta int64, reason string, referenceId uint64, userId string) error {
+	if delta == 0 {
+		return nil
+	}
+
+	movement := new(StockMovement)
+	movement.HeadquarterId = headquarterProduct.HeadquarterId
+	movement.ProductId = headquarterProduct.ProductId
+	movement.Delta = delta
+	movement.Balance = headquarterProduct.Amount
+	movement.Reason = reason
+	movement.ReferenceId = referenceId
+	movement.UserId = userId
+	_, err := session.Insert(movement)
+
+	return err
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	TaxRateTableName = "tax_rate"
+)
+
+// @Description Customer tax rate, e.g. VAT 19% or exempt 0%.
+type TaxRate struct {
+	Id      uint64    `xorm:"pk autoincr" json:"id"`
+	Name    string    `xorm:"not null unique" json:"name"`
+	Rate    float64   `xorm:"not null default 0" json:"rate"`
+	Created time.Time `xorm:"created" json:"created"`
+	Updated time.Time `xorm:"updated" json:"updated"`
+}
+
+func (t *TaxRate) TableName() string {
+	return TaxRateTableName
+}
+
+type TaxRateDao struct {
+	Dao
+}
+
+func NewTaxRateDao(schema string) *TaxRateDao {
+	d := new(TaxRateDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Update the tax rate, the rate is always updated so it can be
+// changed to exempt.
+// @Param taxRate Tax rate.
+func (d *TaxRateDao) Update(taxRate *TaxRate) error {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	found, err := engine.ID(taxRate.Id).Exist(new(TaxRate))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &NotFoundError{Message: fmt.Sprintf("Tax rate %d does not exist.", taxRate.Id)}
+	}
+
+	_, err = engine.ID(taxRate.Id).MustCols("rate").Update(taxRate)
+
+	return err
+}
+
+// @Description Delete the tax rate when no product references it.
+// @Param taxRateId Tax rate Id.
+func (d *TaxRateDao) Delete(taxRateId uint64) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		products, err := session.Where("tax_rate_id = ?", taxRateId).Count(new(Product))
+		if err != nil {
+			return err
+		}
+		if products > 0 {
+			return &ConflictError{Message: fmt.Sprintf("Tax rate %d is used by %d products.", taxRateId, products)}
+		}
+
+		_, err = session.ID(taxRateId).Delete(new(TaxRate))
+
+		return err
+	})
+}
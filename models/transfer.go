@@ -0,0 +1,371 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-xorm/xorm"
+	"time"
+)
+
+var (
+	TransferTableName     = "transfer"
+	TransferLineTableName = "transfer_line"
+)
+
+// Transfer status.
+const (
+	TransferStatusDraft             = "draft"
+	TransferStatusDispatched        = "dispatched"
+	TransferStatusPartiallyReceived = "partially_received"
+	TransferStatusReceived          = "received"
+)
+
+// @Description Stock sent from a headquarter to another one.
+type Transfer struct {
+	Id                uint64          `xorm:"pk autoincr" json:"id"`
+	FromHeadquarterId uint64          `xorm:"index" json:"from_headquarter_id"`
+	ToHeadquarterId   uint64          `xorm:"index" json:"to_headquarter_id"`
+	Status            string          `xorm:"index not null" json:"status"`
+	UserId            string          `json:"user_id"`
+	Notes             string          `json:"notes"`
+	DispatchedBy      string          `json:"dispatched_by"`
+	Dispatched        time.Time       `xorm:"null" json:"dispatched"`
+	ReceivedBy        string          `json:"received_by"`
+	Received          time.Time       `xorm:"null" json:"received"`
+	Lines             []*TransferLine `xorm:"-" json:"lines"`
+	Created           time.Time       `xorm:"created" json:"created"`
+	Updated           time.Time       `xorm:"updated" json:"updated"`
+}
+
+func (t *Transfer) TableName() string {
+	return TransferTableName
+}
+
+// @Description Transferred product. The discrepancy is the dispatched amount
+// not received.
+type TransferLine struct {
+	Id          uint64    `xorm:"pk autoincr" json:"id"`
+	TransferId  uint64    `xorm:"index" json:"transfer_id"`
+	ProductId   uint64    `xorm:"index" json:"product_id"`
+	Amount      uint64    `xorm:"not null" json:"amount"`
+	Received    uint64    `xorm:"not null default 0" json:"received"`
+	Discrepancy uint64    `xorm:"not null default 0" json:"discrepancy"`
+	Notes       string    `json:"notes"`
+	Created     time.Time `xorm:"created" json:"created"`
+	Updated     time.Time `xorm:"updated" json:"updated"`
+}
+
+func (t *TransferLine) TableName() string {
+	return TransferLineTableName
+}
+
+// @Description Dispatched stock not received yet.
+type TransitStock struct {
+	FromHeadquarterId uint64 `json:"from_headquarter_id"`
+	ToHeadquarterId   uint64 `json:"to_headquarter_id"`
+	ProductId         uint64 `json:"product_id"`
+	Amount            uint64 `json:"amount"`
+}
+
+type TransferDao struct {
+	Dao
+}
+
+func NewTransferDao(schema string) *TransferDao {
+	d := new(TransferDao)
+	d.Dao = new(dao)
+	d.SetSchema(schema)
+	return d
+}
+
+// @Description Create the transfer draft with its lines.
+// @Param transfer Transfer with its lines.
+func (d *TransferDao) Create(transfer *Transfer) error {
+	return Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		// Validate headquarters.
+		if transfer.FromHeadquarterId == transfer.ToHeadquarterId {
+			return &ValidationError{Message: "The transfer headquarters must be different."}
+		}
+		for _, headquarterId := range []uint64{transfer.FromHeadquarterId, transfer.ToHeadquarterId} {
+			found, err := session.NoCache().ID(headquarterId).Exist(new(Headquarter))
+			if err != nil {
+				return err
+			}
+			if !found {
+				return &NotFoundError{Message: fmt.Sprintf("Headquarter %d does not exist.", headquarterId)}
+			}
+		}
+
+		// Validate lines.
+		if len(transfer.Lines) == 0 {
+			return &ValidationError{Message: "The transfer must have at least one line."}
+		}
+		products := make(map[uint64]bool)
+		for _, line := range transfer.Lines {
+			if products[line.ProductId] {
+				return &ValidationError{Message: fmt.Sprintf("Product %d is repeated.", line.ProductId)}
+			}
+			products[line.ProductId] = true
+			if line.Amount == 0 {
+				return &ValidationError{Message: fmt.Sprintf("Product %d must have an amount.", line.ProductId)}
+			}
+			found, err := session.NoCache().ID(line.ProductId).Exist(new(Product))
+			if err != nil {
+				return err
+			}
+			if !found {
+				return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist.", line.ProductId)}
+			}
+		}
+
+		// Insert transfer.
+		transfer.Status = TransferStatusDraft
+		_, err := session.Insert(transfer)
+		if err != nil {
+			return err
+		}
+
+		// Insert lines.
+		for _, line := range transfer.Lines {
+			line.Id = 0
+			line.TransferId = transfer.Id
+			line.Received = 0
+			line.Discrepancy = 0
+			_, err = session.Insert(line)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// @Description Get the transfer with its lines.
+// @Param transferId Transfer Id.
+func (d *TransferDao) Read(transferId uint64) (*Transfer, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	transfer := new(Transfer)
+	found, err := session.NoCache().ID(transferId).Get(transfer)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Transfer %d does not exist.", transferId)}
+	}
+
+	transfer.Lines, err = d.findLines(session, transferId)
+
+	return transfer, err
+}
+
+// @Param headquarterId Headquarter Id, origin or destination. 0 for every
+// headquarter.
+// @Param status Transfer status, empty for every status.
+func (d *TransferDao) FindByHeadquarterAndStatus(headquarterId uint64, status string) ([]*Transfer, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Desc("id")
+	if headquarterId > 0 {
+		session = session.And("from_headquarter_id = ? OR to_headquarter_id = ?", headquarterId, headquarterId)
+	}
+	if len(status) > 0 {
+		session = session.And("status = ?", status)
+	}
+
+	transfers := make([]*Transfer, 0)
+	err := session.Find(&transfers)
+
+	return transfers, err
+}
+
+// @Description Dispatch the transfer decreasing the origin headquarter stock.
+// If the failure is due to the stock a StockErrors with every failed line is
+// returned.
+// @Param transferId Transfer Id.
+// @Param userId User dispatching the transfer.
+func (d *TransferDao) Dispatch(transferId uint64, userId string) (*Transfer, error) {
+	var transfer *Transfer
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		transfer, err = d.readForUpdate(session, transferId)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != TransferStatusDraft {
+			return &ConflictError{Message: fmt.Sprintf("Transfer %d is %s.", transferId, transfer.Status)}
+		}
+
+		// Decrease the origin stock.
+		stockErrors := make(StockErrors, 0)
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		for i, line := range transfer.Lines {
+			err = headquarterProductDao.DecreaseStock(session, transfer.FromHeadquarterId, line.ProductId, line.Amount, StockReasonTransfer, transfer.Id, userId)
+			if stockError, ok := err.(*StockError); ok {
+				stockError.Line = i
+				stockErrors = append(stockErrors, stockError)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(stockErrors) > 0 {
+			return stockErrors
+		}
+
+		// Dispatch the transfer.
+		transfer.Status = TransferStatusDispatched
+		transfer.DispatchedBy = userId
+		transfer.Dispatched = time.Now()
+		_, err = session.ID(transfer.Id).Cols("status", "dispatched_by", "dispatched").Update(transfer)
+
+		return err
+	})
+
+	return transfer, err
+}
+
+// @Description Receive transfer lines increasing the destination headquarter
+// stock. The transfer stays partially received until every line is received
+// or it is closed, closing it keeps the missing amounts as discrepancies.
+// @Param transferId Transfer Id.
+// @Param lines Received lines by product, with the received amount and the
+// discrepancy notes.
+// @Param userId User receiving the transfer.
+// @Param notes Receipt notes.
+// @Param close Close the transfer.
+func (d *TransferDao) Receive(transferId uint64, lines []*TransferLine, userId, notes string, close bool) (*Transfer, error) {
+	var transfer *Transfer
+	err := Transaction(d.GetSchema(), func(session *xorm.Session) error {
+		var err error
+		transfer, err = d.readForUpdate(session, transferId)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != TransferStatusDispatched && transfer.Status != TransferStatusPartiallyReceived {
+			return &ConflictError{Message: fmt.Sprintf("Transfer %d is %s.", transferId, transfer.Status)}
+		}
+
+		// Index the transfer lines.
+		transferLines := make(map[uint64]*TransferLine)
+		for _, line := range transfer.Lines {
+			transferLines[line.ProductId] = line
+		}
+
+		// Increase the destination stock.
+		headquarterProductDao := NewHeadquarterProductDao(d.GetSchema())
+		for _, received := range lines {
+			line, ok := transferLines[received.ProductId]
+			if !ok {
+				return &NotFoundError{Message: fmt.Sprintf("Product %d does not exist in transfer %d.", received.ProductId, transferId)}
+			}
+			if received.Received == 0 || line.Received+received.Received > line.Amount {
+				return &ValidationError{Message: fmt.Sprintf("Product %d can not receive %d units. Dispatched %d, Received %d.", line.ProductId, received.Received, line.Amount, line.Received)}
+			}
+
+			err = headquarterProductDao.IncreaseStock(session, transfer.ToHeadquarterId, line.ProductId, received.Received, StockReasonTransfer, transfer.Id, userId)
+			if err != nil {
+				return err
+			}
+
+			line.Received += received.Received
+			if len(received.Notes) > 0 {
+				line.Notes = received.Notes
+			}
+			_, err = session.ID(line.Id).Cols("received", "notes").Update(line)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Resolve the transfer status.
+		complete := true
+		for _, line := range transfer.Lines {
+			complete = complete && line.Received == line.Amount
+		}
+		transfer.Status = TransferStatusPartiallyReceived
+		if complete || close {
+			transfer.Status = TransferStatusReceived
+			transfer.Received = time.Now()
+
+			// Keep the missing amounts.
+			for _, line := range transfer.Lines {
+				line.Discrepancy = line.Amount - line.Received
+				_, err = session.ID(line.Id).Cols("discrepancy").Update(line)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		transfer.ReceivedBy = userId
+		if len(notes) > 0 {
+			transfer.Notes = notes
+		}
+		_, err = session.ID(transfer.Id).Cols("status", "received_by", "received", "notes").Update(transfer)
+
+		return err
+	})
+
+	return transfer, err
+}
+
+// @Description Get the dispatched stock not received yet by destination and
+// product.
+// @Param headquarterId Destination headquarter Id, 0 for every headquarter.
+func (d *TransferDao) InTransit(headquarterId uint64) ([]*TransitStock, error) {
+	// Get engine.
+	engine := GetEngine(d.GetSchema())
+
+	// Build Query.
+	session := engine.Table(TransferLineTableName).
+		Join("INNER", TransferTableName, "transfer.id = transfer_line.transfer_id").
+		Select("transfer.from_headquarter_id, transfer.to_headquarter_id, transfer_line.product_id, SUM(transfer_line.amount - transfer_line.received) AS amount").
+		In("transfer.status", TransferStatusDispatched, TransferStatusPartiallyReceived)
+	if headquarterId > 0 {
+		session = session.And("transfer.to_headquarter_id = ?", headquarterId)
+	}
+
+	stock := make([]*TransitStock, 0)
+	err := session.GroupBy("transfer.from_headquarter_id, transfer.to_headquarter_id, transfer_line.product_id").
+		Having("SUM(transfer_line.amount - transfer_line.received) > 0").
+		Asc("transfer.to_headquarter_id", "transfer_line.product_id").
+		Find(&stock)
+
+	return stock, err
+}
+
+// @Description Lock the transfer row until the transaction ends and get its
+// lines.
+// @Param session Transaction session.
+// @Param transferId Transfer Id.
+func (d *TransferDao) readForUpdate(session *xorm.Session, transferId uint64) (*Transfer, error) {
+	transfer := new(Transfer)
+	found, err := session.NoCache().ForUpdate().ID(transferId).Get(transfer)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Transfer %d does not exist.", transferId)}
+	}
+
+	transfer.Lines, err = d.findLines(session, transferId)
+
+	return transfer, err
+}
+
+// @Param session Session.
+// @Param transferId Transfer Id.
+func (d *TransferDao) findLines(session *xorm.Session, transferId uint64) ([]*TransferLine, error) {
+	lines := make([]*TransferLine, 0)
+	err := session.NoCache().Where("transfer_id = ?", transferId).Asc("id").Find(&lines)
+
+	return lines, err
+}
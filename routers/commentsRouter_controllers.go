@@ -7,6 +7,69 @@ import (
 
 func init() {
 
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"],
+		beego.ControllerComments{
+			Method: "CreateAdjustment",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"],
+		beego.ControllerComments{
+			Method: "GetAdjustment",
+			Router: `/:adjustment_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("adjustment_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"],
+		beego.ControllerComments{
+			Method: "GetAdjustments",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id"),
+				param.New("status"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"],
+		beego.ControllerComments{
+			Method: "GetLosses",
+			Router: `/losses`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id"),
+				param.New("from"),
+				param.New("to"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"],
+		beego.ControllerComments{
+			Method: "ApproveAdjustment",
+			Router: `/:adjustment_id/approve`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("adjustment_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:AdjustmentsController"],
+		beego.ControllerComments{
+			Method: "RejectAdjustment",
+			Router: `/:adjustment_id/reject`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("adjustment_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
 	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
 		beego.ControllerComments{
 			Method: "CreateBill",
@@ -53,6 +116,8 @@ func init() {
 			AllowHTTPMethods: []string{"delete"},
 			MethodParams: param.Make(
 				param.New("bill_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+				param.New("reason", param.IsRequired),
 			),
 			Params: nil})
 
@@ -64,6 +129,7 @@ func init() {
 			MethodParams: param.Make(
 				param.New("bill_id", param.IsRequired, param.InPath),
 				param.New("sale_id", param.IsRequired, param.InPath),
+				param.New("discount_authorized_by"),
 			),
 			Params: nil})
 
@@ -78,6 +144,215 @@ func init() {
 			),
 			Params: nil})
 
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "CreateReturn",
+			Router: `/:bill_id/returns`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "GetReturns",
+			Router: `/:bill_id/returns`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "GetPayments",
+			Router: `/payments`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("from"),
+				param.New("to"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "FindBills",
+			Router: `/numbers/:number`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("number", param.IsRequired, param.InPath),
+				param.New("headquarter_id"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "PreviewBill",
+			Router: `/preview`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "GetReceipt",
+			Router: `/:bill_id/receipt`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+				param.New("format"),
+				param.New("width"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "GetInvoice",
+			Router: `/:bill_id/invoice`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "GetInvoices",
+			Router: `/invoices`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("from"),
+				param.New("to"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "HoldBill",
+			Router: `/held`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "GetHeldBills",
+			Router: `/held`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "ResumeBill",
+			Router: `/:bill_id/resume`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "FinalizeBill",
+			Router: `/:bill_id/finalize`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BillsController"],
+		beego.ControllerComments{
+			Method: "CancelHold",
+			Router: `/:bill_id/hold`,
+			AllowHTTPMethods: []string{"delete"},
+			MethodParams: param.Make(
+				param.New("bill_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "CreateBuyer",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "GetBuyers",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("query"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "GetBuyer",
+			Router: `/:buyer_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("buyer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "UpdateBuyer",
+			Router: `/:buyer_id`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("buyer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "DeleteBuyer",
+			Router: `/:buyer_id`,
+			AllowHTTPMethods: []string{"delete"},
+			MethodParams: param.Make(
+				param.New("buyer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "GetBills",
+			Router: `/:buyer_id/bills`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("buyer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "GetPoints",
+			Router: `/:buyer_id/points`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("buyer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:BuyersController"],
+		beego.ControllerComments{
+			Method: "GetPointsHistory",
+			Router: `/:buyer_id/points/history`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("buyer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
 	beego.GlobalControllerRouter["app-rest-inventory/controllers:CateringsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CateringsController"],
 		beego.ControllerComments{
 			Method: "CreateCatering",
@@ -114,6 +389,74 @@ func init() {
 			AllowHTTPMethods: []string{"patch"},
 			MethodParams: param.Make(
 				param.New("catering_id", param.IsRequired, param.InPath),
+				param.New("user_id"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CateringsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CateringsController"],
+		beego.ControllerComments{
+			Method: "DeleteCatering",
+			Router: `/:catering_id`,
+			AllowHTTPMethods: []string{"delete"},
+			MethodParams: param.Make(
+				param.New("catering_id", param.IsRequired, param.InPath),
+				param.New("user_id"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"],
+		beego.ControllerComments{
+			Method: "CreateCoupon",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"],
+		beego.ControllerComments{
+			Method: "GetCoupons",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"],
+		beego.ControllerComments{
+			Method: "GetCoupon",
+			Router: `/:coupon_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("coupon_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"],
+		beego.ControllerComments{
+			Method: "UpdateCoupon",
+			Router: `/:coupon_id`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("coupon_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"],
+		beego.ControllerComments{
+			Method: "DeleteCoupon",
+			Router: `/:coupon_id`,
+			AllowHTTPMethods: []string{"delete"},
+			MethodParams: param.Make(
+				param.New("coupon_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:CouponsController"],
+		beego.ControllerComments{
+			Method: "GetRedemptions",
+			Router: `/:coupon_id/redemptions`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("coupon_id", param.IsRequired, param.InPath),
 			),
 			Params: nil})
 
@@ -198,6 +541,7 @@ func init() {
 			AllowHTTPMethods: []string{"post"},
 			MethodParams: param.Make(
 				param.New("headquarter_id", param.IsRequired, param.InPath),
+				param.New("user_id"),
 			),
 			Params: nil})
 
@@ -232,6 +576,7 @@ func init() {
 			MethodParams: param.Make(
 				param.New("headquarter_id", param.IsRequired, param.InPath),
 				param.New("product_id", param.IsRequired, param.InPath),
+				param.New("user_id"),
 			),
 			Params: nil})
 
@@ -246,33 +591,161 @@ func init() {
 			),
 			Params: nil})
 
-	beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"],
-		beego.ControllerComments{
-			Method: "CreateProduct",
-			Router: `/`,
-			AllowHTTPMethods: []string{"post"},
-			MethodParams: param.Make(),
-			Params: nil})
-
-	beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"],
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"],
 		beego.ControllerComments{
-			Method: "GetProducts",
-			Router: `/`,
+			Method: "GetPayments",
+			Router: `/:headquarter_id/payments`,
 			AllowHTTPMethods: []string{"get"},
 			MethodParams: param.Make(
-				param.New("name"),
-				param.New("brand"),
-				param.New("color"),
+				param.New("headquarter_id", param.IsRequired, param.InPath),
+				param.New("from"),
+				param.New("to"),
 			),
 			Params: nil})
 
-	beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"],
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"],
 		beego.ControllerComments{
-			Method: "DeleteCatering",
-			Router: `/:catering_id`,
-			AllowHTTPMethods: []string{"delete"},
+			Method: "GetMovements",
+			Router: `/:headquarter_id/products/:product_id/movements`,
+			AllowHTTPMethods: []string{"get"},
 			MethodParams: param.Make(
-				param.New("catering_id", param.IsRequired, param.InPath),
+				param.New("headquarter_id", param.IsRequired, param.InPath),
+				param.New("product_id", param.IsRequired, param.InPath),
+				param.New("from"),
+				param.New("to"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"],
+		beego.ControllerComments{
+			Method: "UpdateLevels",
+			Router: `/:headquarter_id/products/:product_id/levels`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("headquarter_id", param.IsRequired, param.InPath),
+				param.New("product_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"],
+		beego.ControllerComments{
+			Method: "GetLowStock",
+			Router: `/:headquarter_id/products/low-stock`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:HeadquartersController"],
+		beego.ControllerComments{
+			Method: "GetAlerts",
+			Router: `/:headquarter_id/alerts`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id", param.IsRequired, param.InPath),
+				param.New("from"),
+				param.New("to"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "CreateInventoryCount",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "GetInventoryCount",
+			Router: `/:count_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("count_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "GetInventoryCounts",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id"),
+				param.New("status"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "GetVariances",
+			Router: `/:count_id/variances`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("count_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "CountInventory",
+			Router: `/:count_id/entries`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(
+				param.New("count_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "ReconcileInventoryCount",
+			Router: `/:count_id/reconcile`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("count_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "ApproveInventoryCount",
+			Router: `/:count_id/approve`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("count_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:InventoryCountsController"],
+		beego.ControllerComments{
+			Method: "CancelInventoryCount",
+			Router: `/:count_id/cancel`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("count_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"],
+		beego.ControllerComments{
+			Method: "CreateProduct",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ProductsController"],
+		beego.ControllerComments{
+			Method: "GetProducts",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("name"),
+				param.New("brand"),
+				param.New("color"),
 			),
 			Params: nil})
 
@@ -314,6 +787,52 @@ func init() {
 			MethodParams: param.Make(),
 			Params: nil})
 
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"],
+		beego.ControllerComments{
+			Method: "CreatePromotion",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"],
+		beego.ControllerComments{
+			Method: "GetPromotions",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"],
+		beego.ControllerComments{
+			Method: "GetPromotion",
+			Router: `/:promotion_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("promotion_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"],
+		beego.ControllerComments{
+			Method: "UpdatePromotion",
+			Router: `/:promotion_id`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("promotion_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PromotionsController"],
+		beego.ControllerComments{
+			Method: "DeletePromotion",
+			Router: `/:promotion_id`,
+			AllowHTTPMethods: []string{"delete"},
+			MethodParams: param.Make(
+				param.New("promotion_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
 	beego.GlobalControllerRouter["app-rest-inventory/controllers:ProvidersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ProvidersController"],
 		beego.ControllerComments{
 			Method: "CreateProvider",
@@ -360,6 +879,247 @@ func init() {
 			),
 			Params: nil})
 
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"],
+		beego.ControllerComments{
+			Method: "CreatePurchaseOrder",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"],
+		beego.ControllerComments{
+			Method: "GetPurchaseOrder",
+			Router: `/:order_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("order_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"],
+		beego.ControllerComments{
+			Method: "GetPurchaseOrders",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("provider_id"),
+				param.New("headquarter_id"),
+				param.New("status"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"],
+		beego.ControllerComments{
+			Method: "SendPurchaseOrder",
+			Router: `/:order_id/send`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("order_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"],
+		beego.ControllerComments{
+			Method: "ReceivePurchaseOrder",
+			Router: `/:order_id/receive`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("order_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:PurchaseOrdersController"],
+		beego.ControllerComments{
+			Method: "CancelPurchaseOrder",
+			Router: `/:order_id/cancel`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("order_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ReplenishmentController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ReplenishmentController"],
+		beego.ControllerComments{
+			Method: "GetSuggestions",
+			Router: `/suggestions`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id", param.IsRequired),
+				param.New("window_days"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ReplenishmentController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ReplenishmentController"],
+		beego.ControllerComments{
+			Method: "OrderSuggestions",
+			Router: `/orders`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(
+				param.New("headquarter_id", param.IsRequired),
+				param.New("provider_id"),
+				param.New("window_days"),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"],
+		beego.ControllerComments{
+			Method: "OpenShift",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"],
+		beego.ControllerComments{
+			Method: "GetShifts",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id"),
+				param.New("status"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"],
+		beego.ControllerComments{
+			Method: "GetShift",
+			Router: `/:shift_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("shift_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"],
+		beego.ControllerComments{
+			Method: "AddMovement",
+			Router: `/:shift_id/movements`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(
+				param.New("shift_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:ShiftsController"],
+		beego.ControllerComments{
+			Method: "CloseShift",
+			Router: `/:shift_id/close`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("shift_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"],
+		beego.ControllerComments{
+			Method: "CreateTaxRate",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"],
+		beego.ControllerComments{
+			Method: "GetTaxRates",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"],
+		beego.ControllerComments{
+			Method: "GetTaxRate",
+			Router: `/:tax_rate_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("tax_rate_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"],
+		beego.ControllerComments{
+			Method: "UpdateTaxRate",
+			Router: `/:tax_rate_id`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("tax_rate_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TaxRatesController"],
+		beego.ControllerComments{
+			Method: "DeleteTaxRate",
+			Router: `/:tax_rate_id`,
+			AllowHTTPMethods: []string{"delete"},
+			MethodParams: param.Make(
+				param.New("tax_rate_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"],
+		beego.ControllerComments{
+			Method: "CreateTransfer",
+			Router: `/`,
+			AllowHTTPMethods: []string{"post"},
+			MethodParams: param.Make(),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"],
+		beego.ControllerComments{
+			Method: "GetTransfer",
+			Router: `/:transfer_id`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("transfer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"],
+		beego.ControllerComments{
+			Method: "GetTransfers",
+			Router: `/`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id"),
+				param.New("status"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"],
+		beego.ControllerComments{
+			Method: "GetInTransit",
+			Router: `/in-transit`,
+			AllowHTTPMethods: []string{"get"},
+			MethodParams: param.Make(
+				param.New("headquarter_id"),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"],
+		beego.ControllerComments{
+			Method: "DispatchTransfer",
+			Router: `/:transfer_id/dispatch`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("transfer_id", param.IsRequired, param.InPath),
+				param.New("user_id", param.IsRequired),
+			),
+			Params: nil})
+
+	beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:TransfersController"],
+		beego.ControllerComments{
+			Method: "ReceiveTransfer",
+			Router: `/:transfer_id/receive`,
+			AllowHTTPMethods: []string{"patch"},
+			MethodParams: param.Make(
+				param.New("transfer_id", param.IsRequired, param.InPath),
+			),
+			Params: nil})
+
 	beego.GlobalControllerRouter["app-rest-inventory/controllers:UsersController"] = append(beego.GlobalControllerRouter["app-rest-inventory/controllers:UsersController"],
 		beego.ControllerComments{
 			Method: "CreateUser",
@@ -59,16 +59,66 @@ func init() {
 				&controllers.ProductsController{},
 			),
 		),
+		beego.NSNamespace("/buyers",
+			beego.NSInclude(
+				&controllers.BuyersController{},
+			),
+		),
 		beego.NSNamespace("/caterings",
 			beego.NSInclude(
 				&controllers.CateringsController{},
 			),
 		),
+		beego.NSNamespace("/coupons",
+			beego.NSInclude(
+				&controllers.CouponsController{},
+			),
+		),
 		beego.NSNamespace("/providers",
 			beego.NSInclude(
 				&controllers.ProvidersController{},
 			),
 		),
+		beego.NSNamespace("/promotions",
+			beego.NSInclude(
+				&controllers.PromotionsController{},
+			),
+		),
+		beego.NSNamespace("/shifts",
+			beego.NSInclude(
+				&controllers.ShiftsController{},
+			),
+		),
+		beego.NSNamespace("/taxrates",
+			beego.NSInclude(
+				&controllers.TaxRatesController{},
+			),
+		),
+		beego.NSNamespace("/transfers",
+			beego.NSInclude(
+				&controllers.TransfersController{},
+			),
+		),
+		beego.NSNamespace("/adjustments",
+			beego.NSInclude(
+				&controllers.AdjustmentsController{},
+			),
+		),
+		beego.NSNamespace("/inventorycounts",
+			beego.NSInclude(
+				&controllers.InventoryCountsController{},
+			),
+		),
+		beego.NSNamespace("/purchaseorders",
+			beego.NSInclude(
+				&controllers.PurchaseOrdersController{},
+			),
+		),
+		beego.NSNamespace("/replenishment",
+			beego.NSInclude(
+				&controllers.ReplenishmentController{},
+			),
+		),
 	)
 	// Register namespace.
 	beego.AddNamespace(ns)
@@ -5,9 +5,10 @@ import (
 )
 
 type ApiError struct {
-	ErrorCode    string `json:"error_code"`
-	StatusCode   int    `json:"status_code"`
-	ErrorMessage string `json:"error_message"`
+	ErrorCode    string      `json:"error_code"`
+	StatusCode   int         `json:"status_code"`
+	ErrorMessage string      `json:"error_message"`
+	Details      interface{} `json:"details,omitempty"`
 }
 
 func (e *ApiError) Error() string {
@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Notification message for the users.
+type Notification struct {
+	Subject string      `json:"subject"`
+	Body    string      `json:"body"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Notifier delivers the notifications.
+type Notifier interface {
+	Notify(*Notification) error
+}
+
+// NewLog Notifier writing the notifications to the application log.
+func NewLog() Notifier {
+	return new(logNotifier)
+}
+
+type logNotifier struct{}
+
+func (n *logNotifier) Notify(notification *Notification) error {
+	logs.Warn("%s %s", notification.Subject, notification.Body)
+	return nil
+}
+
+// NewWebhook Notifier posting the notifications as JSON.
+// @Param url Webhook URL.
+func NewWebhook(url string) Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n *webhookNotifier) Notify(notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	response, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("Webhook %s responded %d.", n.url, response.StatusCode)
+	}
+
+	return nil
+}
+
+// NewSMTP Notifier sending the notifications by email through an SMTP server
+// without authentication, like a local relay.
+// @Param addr SMTP server address, host:port.
+// @Param from Sender address.
+// @Param to Recipient addresses.
+func NewSMTP(addr, from string, to []string) Notifier {
+	return &smtpNotifier{addr: addr, from: from, to: to}
+}
+
+type smtpNotifier struct {
+	addr string
+	from string
+	to   []string
+}
+
+func (n *smtpNotifier) Notify(notification *Notification) error {
+	if len(n.to) == 0 {
+		return fmt.Errorf("The email notifier does not have recipients.")
+	}
+
+	var message bytes.Buffer
+	message.WriteString("From: " + n.from + "\r\n")
+	message.WriteString("To: " + strings.Join(n.to, ", ") + "\r\n")
+	message.WriteString("Subject: " + notification.Subject + "\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(notification.Body + "\r\n")
+
+	return smtp.SendMail(n.addr, nil, n.from, n.to, message.Bytes())
+}
@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	received := make(chan *Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := new(Notification)
+		if err := json.NewDecoder(r.Body).Decode(notification); err != nil {
+			t.Error(err)
+		}
+		received <- notification
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL).Notify(&Notification{Subject: "Low stock", Body: "Product 1."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notification := <-received
+	if notification.Subject != "Low stock" || notification.Body != "Product 1." {
+		t.Errorf("Unexpected notification %+v.", notification)
+	}
+}
+
+func TestWebhookStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL).Notify(&Notification{Subject: "Low stock"})
+	if err == nil {
+		t.Error("The webhook error status must fail the notification.")
+	}
+}
+
+// SMTP stand-in accepting a single message.
+func smtpStandIn(t *testing.T, data chan<- string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				text.PrintfLine("250 localhost")
+			case "DATA":
+				text.PrintfLine("354 go ahead")
+				lines, _ := text.ReadDotLines()
+				data <- strings.Join(lines, "\n")
+				text.PrintfLine("250 ok")
+			case "QUIT":
+				text.PrintfLine("221 bye")
+				return
+			default:
+				text.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	return listener
+}
+
+func TestSMTP(t *testing.T) {
+	data := make(chan string, 1)
+	listener := smtpStandIn(t, data)
+	defer listener.Close()
+
+	notifier := NewSMTP(listener.Addr().String(), "alerts@localhost", []string{"admin@localhost"})
+	err := notifier.Notify(&Notification{Subject: "Low stock", Body: "Product 1."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-data
+	if !strings.Contains(message, "Subject: Low stock") || !strings.Contains(message, "Product 1.") {
+		t.Errorf("Unexpected message %q.", message)
+	}
+}
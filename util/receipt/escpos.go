@@ -0,0 +1,44 @@
+package receipt
+
+import (
+	"bytes"
+)
+
+// ESC/POS commands.
+var (
+	escInit      = []byte{0x1b, 0x40}
+	escCodePage  = []byte{0x1b, 0x74, 0x10}
+	escAlignLeft = []byte{0x1b, 0x61, 0x00}
+	escBoldOn    = []byte{0x1b, 0x45, 0x01}
+	escBoldOff   = []byte{0x1b, 0x45, 0x00}
+	escFeed      = []byte{0x1b, 0x64, 0x04}
+	escCut       = []byte{0x1d, 0x56, 0x42, 0x00}
+)
+
+// EscPos Renders the receipt as an ESC/POS byte stream using the WPC1252 code
+// page.
+// @Param width Line width in characters, see Width58mm and Width80mm.
+func (r *Receipt) EscPos(width int) []byte {
+	var buffer bytes.Buffer
+	buffer.Write(escInit)
+	buffer.Write(escCodePage)
+
+	// The title goes in bold.
+	title := len(r.Header)
+	for i, line := range r.Text(width) {
+		if i == title {
+			buffer.Write(escBoldOn)
+		}
+		buffer.Write(encode(line))
+		buffer.WriteByte('\n')
+		if i == title {
+			buffer.Write(escBoldOff)
+		}
+	}
+
+	buffer.Write(escAlignLeft)
+	buffer.Write(escFeed)
+	buffer.Write(escCut)
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,84 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PDF page layout in points, A4 with a monospaced font.
+const (
+	pdfWidth     = 595
+	pdfHeight    = 842
+	pdfMargin    = 50
+	pdfFontSize  = 10
+	pdfLeading   = 12
+	pdfLineWidth = 80
+)
+
+// PDF Renders the receipt as a PDF document of A4 pages.
+func (r *Receipt) PDF() []byte {
+	text := r.Text(pdfLineWidth)
+
+	// Split the text in pages.
+	perPage := (pdfHeight - 2*pdfMargin) / pdfLeading
+	pages := make([][]string, 0)
+	for len(text) > perPage {
+		pages = append(pages, text[:perPage])
+		text = text[perPage:]
+	}
+	pages = append(pages, text)
+
+	// Objects: catalog, pages, font and a page and its content by page.
+	objects := make([][]byte, 3)
+	kids := new(bytes.Buffer)
+	for i, page := range pages {
+		pageId := 4 + 2*i
+		fmt.Fprintf(kids, "%d 0 R ", pageId)
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfHeight-pdfMargin)
+		for _, line := range page {
+			content.WriteString("(")
+			content.Write(pdfEscape(encode(line)))
+			content.WriteString(") Tj T*\n")
+		}
+		content.WriteString("ET")
+
+		objects = append(objects, []byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, pageId+1)))
+		objects = append(objects, []byte(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String())))
+	}
+	objects[0] = []byte("<< /Type /Catalog /Pages 2 0 R >>")
+	objects[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids.Bytes()), len(pages)))
+	objects[2] = []byte("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	// Write the document and its cross reference table.
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n", i+1)
+		document.Write(object)
+		document.WriteString("\nendobj\n")
+	}
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return document.Bytes()
+}
+
+// pdfEscape Escapes the PDF string delimiters.
+func pdfEscape(s []byte) []byte {
+	escaped := make([]byte, 0, len(s))
+	for _, c := range s {
+		if c == '(' || c == ')' || c == '\\' {
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, c)
+	}
+	return escaped
+}
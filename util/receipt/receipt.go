@@ -0,0 +1,139 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Paper widths in characters.
+const (
+	Width58mm = 32
+	Width80mm = 48
+)
+
+// Receipt printable document, it is rendered as plain text so every output
+// shares the same layout.
+type Receipt struct {
+	Header   []string
+	Title    string
+	Info     []string
+	Lines    []Line
+	Totals   []Amount
+	Payments []Amount
+	Footer   []string
+}
+
+// Line receipt item.
+type Line struct {
+	Description string
+	Amount      uint64
+	UnitPrice   float64
+	Discount    float64
+	Total       float64
+}
+
+// Amount labeled receipt amount.
+type Amount struct {
+	Label string
+	Value float64
+}
+
+// Text Renders the receipt as plain text lines.
+// @Param width Line width in characters.
+func (r *Receipt) Text(width int) []string {
+	separator := strings.Repeat("-", width)
+	text := make([]string, 0)
+
+	// Header.
+	for _, line := range r.Header {
+		text = append(text, center(line, width))
+	}
+	text = append(text, center(r.Title, width))
+	for _, line := range r.Info {
+		text = append(text, truncate(line, width))
+	}
+	text = append(text, separator)
+
+	// Items.
+	for _, line := range r.Lines {
+		text = append(text, truncate(line.Description, width))
+		text = append(text, columns(fmt.Sprintf("  %d x %.2f", line.Amount, line.UnitPrice), money(line.Total), width))
+		if line.Discount > 0 {
+			text = append(text, columns("  Discount", money(-line.Discount), width))
+		}
+	}
+	text = append(text, separator)
+
+	// Totals.
+	for _, amount := range r.Totals {
+		text = append(text, columns(amount.Label, money(amount.Value), width))
+	}
+	if len(r.Payments) > 0 {
+		text = append(text, separator)
+		for _, amount := range r.Payments {
+			text = append(text, columns(amount.Label, money(amount.Value), width))
+		}
+	}
+
+	// Footer.
+	if len(r.Footer) > 0 {
+		text = append(text, separator)
+		for _, line := range r.Footer {
+			text = append(text, center(line, width))
+		}
+	}
+
+	return text
+}
+
+func money(value float64) string {
+	return fmt.Sprintf("%.2f", value)
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+func center(s string, width int) string {
+	s = truncate(s, width)
+	padding := (width - utf8.RuneCountInString(s)) / 2
+	return strings.Repeat(" ", padding) + s
+}
+
+// columns Aligns the label to the left and the value to the right.
+func columns(label, value string, width int) string {
+	label = truncate(label, width-utf8.RuneCountInString(value)-1)
+	padding := width - utf8.RuneCountInString(label) - utf8.RuneCountInString(value)
+	return label + strings.Repeat(" ", padding) + value
+}
+
+// windows1252 Characters of the 0x80-0x9f range of the Windows-1252 code
+// page, the rest of the upper half matches ISO 8859-1.
+var windows1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode Encodes the text in Windows-1252, the code page of the printers and
+// of the PDF WinAnsiEncoding. Control and unsupported characters are
+// replaced so the text can not inject commands.
+func encode(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		case windows1252[r] > 0:
+			encoded = append(encoded, windows1252[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
@@ -0,0 +1,143 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testReceipt Builds a receipt with lines longer than every paper width.
+func testReceipt(items int) *Receipt {
+	receipt := &Receipt{
+		Header: []string{"Almacén Central de Pruebas con un nombre muy largo", "Calle 10 # 20-30"},
+		Title:  "BILL A-00000042",
+		Info: []string{
+			"Date: 2018-03-10 17:45",
+			"Buyer: Compañía de distribución con una razón social más larga que el papel",
+		},
+		Totals:   []Amount{{"Subtotal", 1000}, {"Tax", 190}, {"TOTAL", 1190}},
+		Payments: []Amount{{"Cash", 1200}, {"Change", 10}},
+		Footer:   []string{"Gracias por su compra, vuelva pronto y recuerde sus puntos €"},
+	}
+	for i := 0; i < items; i++ {
+		receipt.Lines = append(receipt.Lines, Line{
+			Description: fmt.Sprintf("Producto %d con una descripción (larga) que no cabe en el papel", i),
+			Amount:      3,
+			UnitPrice:   12345.5,
+			Discount:    10,
+			Total:       37026.5,
+		})
+	}
+	return receipt
+}
+
+func TestTextWidth(t *testing.T) {
+	for _, width := range []int{Width58mm, Width80mm, pdfLineWidth} {
+		for i, line := range testReceipt(3).Text(width) {
+			if utf8.RuneCountInString(line) > width {
+				t.Errorf("Width %d line %d has %d characters: %q.", width, i, utf8.RuneCountInString(line), line)
+			}
+		}
+	}
+}
+
+func TestEscPos(t *testing.T) {
+	for _, width := range []int{Width58mm, Width80mm} {
+		stream := testReceipt(3).EscPos(width)
+
+		// The stream selects the WPC1252 code page after the init.
+		prefix := append(append([]byte{}, escInit...), escCodePage...)
+		if !bytes.HasPrefix(stream, prefix) {
+			t.Fatalf("Width %d stream starts with % x.", width, stream[:len(prefix)])
+		}
+		suffix := append(append(append([]byte{}, escAlignLeft...), escFeed...), escCut...)
+		if !bytes.HasSuffix(stream, suffix) {
+			t.Fatalf("Width %d stream does not end with the feed and cut.", width)
+		}
+
+		// Every printed line fits the paper, a byte by character.
+		body := stream[len(prefix) : len(stream)-len(suffix)]
+		body = bytes.Replace(body, escBoldOn, nil, -1)
+		body = bytes.Replace(body, escBoldOff, nil, -1)
+		lines := bytes.Split(bytes.TrimSuffix(body, []byte("\n")), []byte("\n"))
+		if len(lines) != len(testReceipt(3).Text(width)) {
+			t.Errorf("Width %d stream has %d lines, expected %d.", width, len(lines), len(testReceipt(3).Text(width)))
+		}
+		for i, line := range lines {
+			if len(line) > width {
+				t.Errorf("Width %d line %d has %d bytes: %q.", width, i, len(line), line)
+			}
+			for _, c := range line {
+				if c < 0x20 || c == 0x7f {
+					t.Errorf("Width %d line %d has the control byte %#x.", width, i, c)
+				}
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []byte
+	}{
+		{"Total", []byte("Total")},
+		{"Compañía", []byte{'C', 'o', 'm', 'p', 'a', 0xf1, 0xed, 'a'}},
+		{"5 €", []byte{'5', ' ', 0x80}},
+		{"“ok”", []byte{0x93, 'o', 'k', 0x94}},
+		{"日本", []byte("??")},
+		{"a\x1bd", []byte("a?d")},
+	}
+
+	for _, test := range tests {
+		if encoded := encode(test.text); !bytes.Equal(encoded, test.expected) {
+			t.Errorf("%q encoded % x, expected % x.", test.text, encoded, test.expected)
+		}
+	}
+}
+
+func TestPDF(t *testing.T) {
+	// Enough lines for several pages.
+	document := testReceipt(60).PDF()
+	if !bytes.HasPrefix(document, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+		t.Fatal("The document does not have the PDF header and end.")
+	}
+
+	// Read the trailer.
+	trailer := regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R >>\nstartxref\n(\d+)\n%%EOF\n$`).FindSubmatch(document)
+	if trailer == nil {
+		t.Fatal("The document does not have a valid trailer.")
+	}
+	size, _ := strconv.Atoi(string(trailer[1]))
+	xref, _ := strconv.Atoi(string(trailer[2]))
+
+	// Read the cross reference table.
+	if !bytes.HasPrefix(document[xref:], []byte(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", size))) {
+		t.Fatalf("startxref %d does not point to the cross reference table.", xref)
+	}
+	entries := strings.Split(string(document[xref:]), "\n")[3 : 3+size-1]
+	for i, entry := range entries {
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("Invalid cross reference entry %q.", entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if !bytes.HasPrefix(document[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("Object %d is not at offset %d.", i+1, offset)
+		}
+	}
+
+	// Validate the page count and the stream lengths.
+	pages := len(regexp.MustCompile(`/Type /Page /Parent`).FindAll(document, -1))
+	if pages < 2 || size != 4+2*pages {
+		t.Errorf("%d pages in %d objects.", pages, size-1)
+	}
+	for _, match := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(document, -1) {
+		if length, _ := strconv.Atoi(string(match[1])); length != len(match[2]) {
+			t.Errorf("Stream length %d, expected %d.", length, len(match[2]))
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package ubl
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// UBL 2.1 namespaces.
+const (
+	InvoiceNamespace = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	CacNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	CbcNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	Version = "2.1"
+
+	// UN/CEFACT 1001 commercial invoice.
+	InvoiceTypeCode = "380"
+	// UN/ECE rec 20 unit.
+	UnitCode = "C62"
+	// UN/ECE 5153 value added tax.
+	TaxSchemeVAT = "VAT"
+
+	// Customer party of the sales without an identified buyer.
+	FinalConsumer = "Consumidor final"
+)
+
+// Invoice UBL 2.1 invoice document. The fields follow the schema sequence
+// order, which encoding/xml keeps when marshalling.
+type Invoice struct {
+	XMLName                 xml.Name           `xml:"Invoice"`
+	Xmlns                   string             `xml:"xmlns,attr"`
+	XmlnsCac                string             `xml:"xmlns:cac,attr"`
+	XmlnsCbc                string             `xml:"xmlns:cbc,attr"`
+	UBLVersionID            string             `xml:"cbc:UBLVersionID"`
+	ID                      string             `xml:"cbc:ID"`
+	IssueDate               string             `xml:"cbc:IssueDate"`
+	IssueTime               string             `xml:"cbc:IssueTime,omitempty"`
+	InvoiceTypeCode         string             `xml:"cbc:InvoiceTypeCode"`
+	Note                    string             `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode    string             `xml:"cbc:DocumentCurrencyCode"`
+	AccountingSupplierParty PartyWrapper       `xml:"cac:AccountingSupplierParty"`
+	AccountingCustomerParty PartyWrapper       `xml:"cac:AccountingCustomerParty"`
+	AllowanceCharges        []AllowanceCharge  `xml:"cac:AllowanceCharge"`
+	TaxTotal                TaxTotal           `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal      LegalMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines            []InvoiceLine      `xml:"cac:InvoiceLine"`
+}
+
+// NewInvoice Builds an invoice with the UBL namespaces and version.
+// @Param id Invoice number.
+// @Param currency ISO 4217 currency code.
+func NewInvoice(id, currency string) *Invoice {
+	invoice := new(Invoice)
+	invoice.Xmlns = InvoiceNamespace
+	invoice.XmlnsCac = CacNamespace
+	invoice.XmlnsCbc = CbcNamespace
+	invoice.UBLVersionID = Version
+	invoice.ID = id
+	invoice.InvoiceTypeCode = InvoiceTypeCode
+	invoice.DocumentCurrencyCode = currency
+	invoice.AccountingCustomerParty.Party.PartyName.Name = FinalConsumer
+	return invoice
+}
+
+// Marshal Renders the invoice XML document.
+func (i *Invoice) Marshal() ([]byte, error) {
+	document, err := xml.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), document...), nil
+}
+
+type PartyWrapper struct {
+	Party Party `xml:"cac:Party"`
+}
+
+type Party struct {
+	PartyIdentification *Identification `xml:"cac:PartyIdentification,omitempty"`
+	PartyName           PartyName       `xml:"cac:PartyName"`
+	PostalAddress       *PostalAddress  `xml:"cac:PostalAddress,omitempty"`
+	Contact             *Contact        `xml:"cac:Contact,omitempty"`
+}
+
+type Identification struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type PartyName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type PostalAddress struct {
+	StreetName string   `xml:"cbc:StreetName,omitempty"`
+	Country    *Country `xml:"cac:Country,omitempty"`
+}
+
+type Country struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type Contact struct {
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type Amount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+// NewAmount Builds an amount with two decimals.
+// @Param value Amount value.
+// @Param currency ISO 4217 currency code.
+func NewAmount(value float64, currency string) Amount {
+	return Amount{Value: strconv.FormatFloat(value, 'f', 2, 64), CurrencyID: currency}
+}
+
+type Quantity struct {
+	Value    uint64 `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+type AllowanceCharge struct {
+	ChargeIndicator       bool   `xml:"cbc:ChargeIndicator"`
+	AllowanceChargeReason string `xml:"cbc:AllowanceChargeReason,omitempty"`
+	Amount                Amount `xml:"cbc:Amount"`
+}
+
+type TaxTotal struct {
+	TaxAmount    Amount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []TaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type TaxSubtotal struct {
+	TaxableAmount Amount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     Amount      `xml:"cbc:TaxAmount"`
+	TaxCategory   TaxCategory `xml:"cac:TaxCategory"`
+}
+
+type TaxCategory struct {
+	Percent   string    `xml:"cbc:Percent"`
+	TaxScheme TaxScheme `xml:"cac:TaxScheme"`
+}
+
+type TaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type LegalMonetaryTotal struct {
+	LineExtensionAmount  Amount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount   Amount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount   Amount `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount Amount `xml:"cbc:AllowanceTotalAmount"`
+	PayableAmount        Amount `xml:"cbc:PayableAmount"`
+}
+
+type InvoiceLine struct {
+	ID                  string            `xml:"cbc:ID"`
+	InvoicedQuantity    Quantity          `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount Amount            `xml:"cbc:LineExtensionAmount"`
+	AllowanceCharges    []AllowanceCharge `xml:"cac:AllowanceCharge"`
+	Item                Item              `xml:"cac:Item"`
+	Price               Price             `xml:"cac:Price"`
+}
+
+type Item struct {
+	Name                      string          `xml:"cbc:Name"`
+	SellersItemIdentification *Identification `xml:"cac:SellersItemIdentification,omitempty"`
+	ClassifiedTaxCategory     TaxCategory     `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type Price struct {
+	PriceAmount Amount `xml:"cbc:PriceAmount"`
+}
@@ -0,0 +1,305 @@
+package ubl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// schemaType Children of an UBL 2.1 element in the schema sequence order
+// and the required ones.
+type schemaType struct {
+	sequence []string
+	required []string
+}
+
+// Sequences of the UBL 2.1 Invoice-2 document and of the aggregate components
+// the exporter renders, from the maindoc and common aggregate components
+// schemas.
+var (
+	partyType = schemaType{
+		sequence: []string{"MarkCareIndicator", "MarkAttentionIndicator", "WebsiteURI", "LogoReferenceID", "EndpointID", "IndustryClassificationCode", "PartyIdentification", "PartyName", "Language", "PostalAddress", "PhysicalLocation", "PartyTaxScheme", "PartyLegalEntity", "Contact", "Person", "AgentParty", "ServiceProviderParty", "PowerOfAttorney", "FinancialAccount"},
+	}
+	taxCategoryType = schemaType{
+		sequence: []string{"ID", "Name", "Percent", "BaseUnitMeasure", "PerUnitAmount", "TaxExemptionReasonCode", "TaxExemptionReason", "TierRange", "TierRatePercent", "TaxScheme"},
+		required: []string{"TaxScheme"},
+	}
+	identificationType = schemaType{
+		sequence: []string{"ID"},
+		required: []string{"ID"},
+	}
+
+	schema = map[string]schemaType{
+		"Invoice": {
+			sequence: []string{"UBLExtensions", "UBLVersionID", "CustomizationID", "ProfileID", "ProfileExecutionID", "ID", "CopyIndicator", "UUID", "IssueDate", "IssueTime", "DueDate", "InvoiceTypeCode", "Note", "TaxPointDate", "DocumentCurrencyCode", "TaxCurrencyCode", "PricingCurrencyCode", "PaymentCurrencyCode", "PaymentAlternativeCurrencyCode", "AccountingCostCode", "AccountingCost", "LineCountNumeric", "BuyerReference", "InvoicePeriod", "OrderReference", "BillingReference", "DespatchDocumentReference", "ReceiptDocumentReference", "StatementDocumentReference", "OriginatorDocumentReference", "ContractDocumentReference", "AdditionalDocumentReference", "ProjectReference", "Signature", "AccountingSupplierParty", "AccountingCustomerParty", "PayeeParty", "BuyerCustomerParty", "SellerSupplierParty", "TaxRepresentativeParty", "Delivery", "DeliveryTerms", "PaymentMeans", "PaymentTerms", "PrepaidPayment", "AllowanceCharge", "TaxExchangeRate", "PricingExchangeRate", "PaymentExchangeRate", "PaymentAlternativeExchangeRate", "TaxTotal", "WithholdingTaxTotal", "LegalMonetaryTotal", "InvoiceLine"},
+			required: []string{"ID", "IssueDate", "AccountingSupplierParty", "AccountingCustomerParty", "LegalMonetaryTotal", "InvoiceLine"},
+		},
+		"AccountingSupplierParty": {
+			sequence: []string{"CustomerAssignedAccountID", "AdditionalAccountID", "DataSendingCapability", "Party", "DespatchContact", "AccountingContact", "SellerContact"},
+		},
+		"AccountingCustomerParty": {
+			sequence: []string{"CustomerAssignedAccountID", "SupplierAssignedAccountID", "AdditionalAccountID", "Party", "DeliveryContact", "AccountingContact", "BuyerContact"},
+		},
+		"Party":               partyType,
+		"PartyIdentification": identificationType,
+		"PartyName": {
+			sequence: []string{"Name"},
+			required: []string{"Name"},
+		},
+		"PostalAddress": {
+			sequence: []string{"ID", "AddressTypeCode", "AddressFormatCode", "Postbox", "Floor", "Room", "StreetName", "AdditionalStreetName", "BlockName", "BuildingName", "BuildingNumber", "InhouseMail", "Department", "MarkAttention", "MarkCare", "PlotIdentification", "CitySubdivisionName", "CityName", "PostalZone", "CountrySubentity", "CountrySubentityCode", "Region", "District", "TimezoneOffset", "AddressLine", "Country", "LocationCoordinate"},
+		},
+		"Country": {
+			sequence: []string{"IdentificationCode", "Name"},
+		},
+		"Contact": {
+			sequence: []string{"ID", "Name", "Telephone", "Telefax", "ElectronicMail", "Note", "OtherCommunication"},
+		},
+		"AllowanceCharge": {
+			sequence: []string{"ID", "ChargeIndicator", "AllowanceChargeReasonCode", "AllowanceChargeReason", "MultiplierFactorNumeric", "PrepaidIndicator", "SequenceNumeric", "Amount", "BaseAmount", "AccountingCostCode", "AccountingCost", "PerUnitAmount", "TaxCategory", "TaxTotal", "PaymentMeans"},
+			required: []string{"ChargeIndicator", "Amount"},
+		},
+		"TaxTotal": {
+			sequence: []string{"TaxAmount", "RoundingAmount", "TaxEvidenceIndicator", "TaxIncludedIndicator", "TaxSubtotal"},
+			required: []string{"TaxAmount"},
+		},
+		"TaxSubtotal": {
+			sequence: []string{"TaxableAmount", "TaxAmount", "CalculationSequenceNumeric", "TransactionCurrencyTaxAmount", "Percent", "BaseUnitMeasure", "PerUnitAmount", "TierRange", "TierRatePercent", "TaxCategory"},
+			required: []string{"TaxAmount", "TaxCategory"},
+		},
+		"TaxCategory":           taxCategoryType,
+		"ClassifiedTaxCategory": taxCategoryType,
+		"TaxScheme": {
+			sequence: []string{"ID", "Name", "TaxTypeCode", "CurrencyCode", "JurisdictionRegionAddress"},
+		},
+		"LegalMonetaryTotal": {
+			sequence: []string{"LineExtensionAmount", "TaxExclusiveAmount", "TaxInclusiveAmount", "AllowanceTotalAmount", "ChargeTotalAmount", "PrepaidAmount", "PayableRoundingAmount", "PayableAmount", "PayableAlternativeAmount"},
+			required: []string{"PayableAmount"},
+		},
+		"InvoiceLine": {
+			sequence: []string{"ID", "UUID", "Note", "InvoicedQuantity", "LineExtensionAmount", "TaxPointDate", "AccountingCostCode", "AccountingCost", "PaymentPurposeCode", "FreeOfChargeIndicator", "InvoicePeriod", "OrderLineReference", "DespatchLineReference", "ReceiptLineReference", "BillingReference", "DocumentReference", "PricingReference", "OriginatorParty", "Delivery", "PaymentTerms", "AllowanceCharge", "TaxTotal", "WithholdingTaxTotal", "Item", "Price", "DeliveryTerms", "SubInvoiceLine", "ItemPriceExtension"},
+			required: []string{"ID", "LineExtensionAmount", "Item"},
+		},
+		"Item": {
+			sequence: []string{"Description", "PackQuantity", "PackSizeNumeric", "CatalogueIndicator", "Name", "HazardousRiskIndicator", "AdditionalInformation", "Keyword", "BrandName", "ModelName", "BuyersItemIdentification", "SellersItemIdentification", "ManufacturersItemIdentification", "StandardItemIdentification", "CatalogueItemIdentification", "AdditionalItemIdentification", "CatalogueDocumentReference", "ItemSpecificationDocumentReference", "OriginCountry", "CommodityClassification", "TransactionConditions", "HazardousItem", "ClassifiedTaxCategory", "AdditionalItemProperty", "ManufacturerParty", "InformationContentProviderParty", "OriginAddress", "ItemInstance", "Certificate", "Dimension"},
+		},
+		"SellersItemIdentification": identificationType,
+		"Price": {
+			sequence: []string{"PriceAmount", "BaseQuantity", "PriceChangeReason", "PriceTypeCode", "PriceType", "OrientationInDegreesMeasure", "ValidityPeriod", "PriceList", "AllowanceCharge", "PricingExchangeRate"},
+			required: []string{"PriceAmount"},
+		},
+	}
+)
+
+// testInvoice Builds an invoice with every element the exporter renders.
+func testInvoice() *Invoice {
+	invoice := NewInvoice("A-00000001", "USD")
+	invoice.IssueDate = "2018-01-02"
+	invoice.AccountingSupplierParty.Party = Party{
+		PartyName:     PartyName{Name: "Customer"},
+		PostalAddress: &PostalAddress{StreetName: "Street 1", Country: &Country{IdentificationCode: "CO"}},
+		Contact:       &Contact{Telephone: "555"},
+	}
+	invoice.AccountingCustomerParty.Party = Party{
+		PartyIdentification: &Identification{ID: "123"},
+		PartyName:           PartyName{Name: "Buyer"},
+		PostalAddress:       &PostalAddress{StreetName: "Street 2"},
+		Contact:             &Contact{Telephone: "556", ElectronicMail: "buyer@example.com"},
+	}
+	invoice.AllowanceCharges = []AllowanceCharge{{AllowanceChargeReason: "Discount", Amount: NewAmount(1, "USD")}}
+	invoice.TaxTotal = TaxTotal{TaxAmount: NewAmount(1.9, "USD"), TaxSubtotals: []TaxSubtotal{{
+		TaxableAmount: NewAmount(10, "USD"),
+		TaxAmount:     NewAmount(1.9, "USD"),
+		TaxCategory:   TaxCategory{Percent: "19", TaxScheme: TaxScheme{ID: TaxSchemeVAT}},
+	}}}
+	invoice.InvoiceLines = []InvoiceLine{{
+		ID:                  "1",
+		InvoicedQuantity:    Quantity{Value: 1, UnitCode: UnitCode},
+		LineExtensionAmount: NewAmount(10, "USD"),
+		AllowanceCharges:    []AllowanceCharge{{AllowanceChargeReason: "Promotion", Amount: NewAmount(1, "USD")}},
+		Item: Item{
+			Name:                      "Product",
+			SellersItemIdentification: &Identification{ID: "7"},
+			ClassifiedTaxCategory:     TaxCategory{Percent: "19", TaxScheme: TaxScheme{ID: TaxSchemeVAT}},
+		},
+		Price: Price{PriceAmount: NewAmount(11, "USD")},
+	}}
+	return invoice
+}
+
+// finalConsumerInvoice Builds an invoice of a bill without buyer.
+func finalConsumerInvoice() *Invoice {
+	invoice := testInvoice()
+	invoice.AccountingCustomerParty = NewInvoice("", "").AccountingCustomerParty
+	return invoice
+}
+
+// The UBL 2.1 XSD files can not be vendored, the test checks the schema
+// rules the exporter can break: namespaces, and the order and the required
+// children of every aggregate element.
+func TestInvoiceStructure(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		invoice *Invoice
+	}{
+		{"buyer", testInvoice()},
+		{"final consumer", finalConsumerInvoice()},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			document, err := test.invoice.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, err := range validateStructure(document) {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// The checker must catch the nested order mistakes, not only the top level
+// ones.
+func TestValidateStructure(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		valid    bool
+	}{
+		{name: "party in order", document: `<cac:Party><cac:PartyIdentification><cbc:ID>1</cbc:ID></cac:PartyIdentification><cac:PartyName><cbc:Name>A</cbc:Name></cac:PartyName></cac:Party>`, valid: true},
+		{name: "party out of order", document: `<cac:Party><cac:PartyName><cbc:Name>A</cbc:Name></cac:PartyName><cac:PartyIdentification><cbc:ID>1</cbc:ID></cac:PartyIdentification></cac:Party>`},
+		{name: "tax subtotal out of order", document: `<cac:TaxSubtotal><cbc:TaxAmount>1</cbc:TaxAmount><cbc:TaxableAmount>1</cbc:TaxableAmount><cac:TaxCategory><cac:TaxScheme/></cac:TaxCategory></cac:TaxSubtotal>`},
+		{name: "tax subtotal without category", document: `<cac:TaxSubtotal><cbc:TaxAmount>1</cbc:TaxAmount></cac:TaxSubtotal>`},
+		{name: "invoice line price before item", document: `<cac:InvoiceLine><cbc:ID>1</cbc:ID><cbc:LineExtensionAmount>1</cbc:LineExtensionAmount><cac:Price><cbc:PriceAmount>1</cbc:PriceAmount></cac:Price><cac:Item/></cac:InvoiceLine>`},
+		{name: "unknown element", document: `<cac:PartyName><cbc:Name>A</cbc:Name><cbc:Nickname>B</cbc:Nickname></cac:PartyName>`},
+	}
+
+	for _, test := range tests {
+		document := `<cac:Fragment xmlns:cac="` + CacNamespace + `" xmlns:cbc="` + CbcNamespace + `">` + test.document + `</cac:Fragment>`
+		errs := validateElement(xml.NewDecoder(strings.NewReader(document)), nil)
+		if test.valid && len(errs) > 0 {
+			t.Errorf("%s: %v", test.name, errs)
+		}
+		if !test.valid && len(errs) == 0 {
+			t.Errorf("%s: expected a structure error.", test.name)
+		}
+	}
+}
+
+// validateStructure Validates the invoice document root and elements.
+func validateStructure(document []byte) []error {
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return []error{err}
+		}
+		if root, ok := token.(xml.StartElement); ok {
+			if root.Name.Space != InvoiceNamespace || root.Name.Local != "Invoice" {
+				return []error{fmt.Errorf("Unexpected root element %v.", root.Name)}
+			}
+			return validateElement(decoder, &root)
+		}
+	}
+}
+
+// validateElement Validates the children of the element, recursively, until
+// its end. The children of the elements out of the schema table are not
+// validated. A nil element validates a document fragment.
+func validateElement(decoder *xml.Decoder, element *xml.StartElement) []error {
+	errs := make([]error, 0)
+	var parent schemaType
+	known := false
+	if element != nil {
+		parent, known = schema[element.Name.Local]
+	}
+	position := 0
+	children := make(map[string]bool)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return errs
+		}
+		if err != nil {
+			return append(errs, err)
+		}
+
+		switch child := token.(type) {
+		case xml.StartElement:
+			if child.Name.Space != CacNamespace && child.Name.Space != CbcNamespace {
+				errs = append(errs, fmt.Errorf("Element %s has namespace %s.", child.Name.Local, child.Name.Space))
+			}
+
+			// Validate the sequence order.
+			if known {
+				for position < len(parent.sequence) && parent.sequence[position] != child.Name.Local {
+					position++
+				}
+				if position == len(parent.sequence) {
+					errs = append(errs, fmt.Errorf("Element %s is out of the %s sequence.", child.Name.Local, element.Name.Local))
+					position = 0
+				}
+			}
+			children[child.Name.Local] = true
+			errs = append(errs, validateElement(decoder, &child)...)
+		case xml.EndElement:
+			// Validate the required elements.
+			for _, name := range parent.required {
+				if !children[name] {
+					errs = append(errs, fmt.Errorf("Required element %s of %s is missing.", name, element.Name.Local))
+				}
+			}
+			return errs
+		}
+	}
+}
+
+func TestFinalConsumer(t *testing.T) {
+	document, err := finalConsumerInvoice().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(document, []byte("<cbc:Name>"+FinalConsumer+"</cbc:Name>")) {
+		t.Errorf("The customer party is not the final consumer:\n%s", document)
+	}
+}
+
+// TestInvoiceXSD Validates the invoice against the UBL 2.1 schema with
+// xmllint. UBL_XSD is the path of the UBL-Invoice-2.1.xsd file of the
+// OASIS distribution, the test is skipped without it.
+func TestInvoiceXSD(t *testing.T) {
+	schema := os.Getenv("UBL_XSD")
+	if len(schema) == 0 {
+		t.Skip("UBL_XSD is not set.")
+	}
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed.")
+	}
+
+	for _, invoice := range []*Invoice{testInvoice(), finalConsumerInvoice()} {
+		document, err := invoice.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := ioutil.TempFile("", "invoice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		if _, err = file.Write(document); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		output, err := exec.Command(xmllint, "--noout", "--schema", schema, file.Name()).CombinedOutput()
+		if err != nil {
+			t.Errorf("%v\n%s", err, output)
+		}
+	}
+}